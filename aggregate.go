@@ -0,0 +1,422 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+// void go_sqlite3_agg_step(sqlite3_context *ctx, uintptr_t h, int argc, sqlite3_value **argv);
+// void go_sqlite3_agg_inverse(sqlite3_context *ctx, uintptr_t h, int argc, sqlite3_value **argv);
+// void go_sqlite3_agg_final(sqlite3_context *ctx, uintptr_t h);
+// void go_sqlite3_agg_value(sqlite3_context *ctx, uintptr_t h);
+// void go_sqlite3_destroy(uintptr_t h);
+// static void agg_step(sqlite3_context *ctx, int argc, sqlite3_value **argv){
+//   go_sqlite3_agg_step(ctx, (uintptr_t)sqlite3_user_data(ctx), argc, argv);
+// }
+// static void agg_inverse(sqlite3_context *ctx, int argc, sqlite3_value **argv){
+//   go_sqlite3_agg_inverse(ctx, (uintptr_t)sqlite3_user_data(ctx), argc, argv);
+// }
+// static void agg_final(sqlite3_context *ctx){
+//   go_sqlite3_agg_final(ctx, (uintptr_t)sqlite3_user_data(ctx));
+// }
+// static void agg_value(sqlite3_context *ctx){
+//   go_sqlite3_agg_value(ctx, (uintptr_t)sqlite3_user_data(ctx));
+// }
+// static void agg_destroy(void *h){
+//   go_sqlite3_destroy((uintptr_t)h);
+// }
+// static int create_aggregate(sqlite3 *db, const char *name, uintptr_t h){
+//   return sqlite3_create_function_v2(db, name, -1, SQLITE_UTF8, (void*)h, NULL, agg_step, agg_final, agg_destroy);
+// }
+// static int create_window_function(sqlite3 *db, const char *name, uintptr_t h){
+//   return sqlite3_create_window_function(db, name, -1, SQLITE_UTF8, (void*)h, agg_step, agg_final, agg_value, agg_inverse, agg_destroy);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/cgo"
+	"slices"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Aggregator is the state of an SQL aggregate function (see
+// RegisterAggregate()); a new one is made per group. The args are
+// int64, float64, string, []byte or nil (NULL). The result of Final()
+// is converted as a place holder value is bound (see Exec()); if it is
+// an error, it is the error of the SQL statement.
+type Aggregator interface {
+	// Step adds the args of a row.
+	Step(args ...any) error
+
+	// Final returns the result of the group.
+	Final() any
+}
+
+// WindowAggregator is an Aggregator that can also be used as a window
+// function; i.e. with an OVER clause.
+// See: https://www.sqlite.org/windowfunctions.html#udfwinfunc
+type WindowAggregator interface {
+	Aggregator
+
+	// Inverse removes the args of a row (that Step() added)
+	// that has left the window.
+	Inverse(args ...any) error
+
+	// Value returns the current result of the window.
+	Value() any
+}
+
+// goAggregate is an aggregate function; see RegisterAggregate().
+type goAggregate struct {
+	name     string
+	newState func() Aggregator
+}
+
+// RegisterAggregate registers an SQL aggregate function; newState makes
+// the state of a group (called once per group). If the state is a
+// WindowAggregator, the function is also a window function. It takes
+// any number of args. e.g.
+//
+//	type sumSquares struct{ sum float64 }
+//
+//	func (s *sumSquares) Step(args ...any) error {
+//		if v, ok := args[0].(float64); ok {
+//			s.sum += v * v
+//		}
+//		return nil
+//	}
+//
+//	func (s *sumSquares) Final() any { return s.sum }
+//	...
+//	err := db.RegisterAggregate("sum_squares", func() gosqlite.Aggregator { return &sumSquares{} })
+//
+// As with RegisterFunc(), the function is registered on all connections
+// of the database; a function with the same name is replaced. See
+// RegisterStatAggregates() for median(), percentile() and weighted_avg().
+// See: https://www.sqlite.org/c3ref/create_function.html
+func (d *DB) RegisterAggregate(name string, newState func() Aggregator) error {
+	if d == nil || d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+	if name == "" {
+		return errors.New("function name is empty")
+	}
+	if len(name) > 255 {
+		return errors.New("function name is longer than 255 bytes")
+	}
+	if newState == nil {
+		return fmt.Errorf("%s: newState is nil", name)
+	}
+
+	// see if it is a window function
+	state := newState()
+	if state == nil {
+		return fmt.Errorf("%s: newState returned nil", name)
+	}
+	_, window := state.(WindowAggregator)
+
+	a := goAggregate{name: name, newState: newState}
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return d.registry.add(d.DBHwnd, fmt.Sprintf("func/%s/%d", name, -1), func(hwnd *C.sqlite3) error {
+		cName := C.CString(name)
+		defer C.free(unsafe.Pointer(cName))
+
+		// h is deleted by sqlite3, incl. on error
+		h := cgo.NewHandle(&a)
+
+		var rc C.int
+		if window {
+			rc = C.create_window_function(hwnd, cName, C.uintptr_t(h))
+		} else {
+			rc = C.create_aggregate(hwnd, cName, C.uintptr_t(h))
+		}
+		if rc != SQLITE_OK {
+			return getSQLiteErr(rc, hwnd)
+		}
+
+		return nil
+	})
+}
+
+// state returns the state of the group of ctx; it is made on the first
+// call, if create is true. The handle of the state is kept in the
+// aggregate context of sqlite3.
+func (a *goAggregate) state(ctx *C.sqlite3_context, create bool) (Aggregator, *C.uintptr_t) {
+	size := C.int(0)
+	if create {
+		size = C.int(unsafe.Sizeof(C.uintptr_t(0)))
+	}
+
+	slot := (*C.uintptr_t)(C.sqlite3_aggregate_context(ctx, size))
+	if slot == nil {
+		// no row was added (or out of memory)
+		return nil, nil
+	}
+	if *slot == 0 {
+		if !create {
+			return nil, slot
+		}
+		*slot = C.uintptr_t(cgo.NewHandle(a.newState()))
+	}
+
+	return cgo.Handle(*slot).Value().(Aggregator), slot
+}
+
+// step calls Step() (or Inverse()) of the state with the args.
+func (a *goAggregate) step(ctx *C.sqlite3_context, argv []*C.sqlite3_value, inverse bool) {
+	defer a.recover(ctx)
+
+	agg, slot := a.state(ctx, true)
+	if slot == nil {
+		C.sqlite3_result_error_nomem(ctx)
+		return
+	}
+
+	args := make([]any, len(argv))
+	for i := range argv {
+		args[i] = sqlValue(argv[i])
+	}
+
+	var err error
+	if inverse {
+		err = agg.(WindowAggregator).Inverse(args...)
+	} else {
+		err = agg.Step(args...)
+	}
+	if err != nil {
+		resultError(ctx, fmt.Errorf("%s: %w", a.name, err))
+	}
+}
+
+// final sets the result of the group (or of the current window,
+// if final is false); the state is released by final.
+func (a *goAggregate) final(ctx *C.sqlite3_context, final bool) {
+	defer a.recover(ctx)
+
+	agg, slot := a.state(ctx, false)
+	if final && slot != nil && *slot != 0 {
+		h := cgo.Handle(*slot)
+		*slot = 0
+		defer h.Delete()
+	}
+	if agg == nil {
+		// an empty group
+		agg = a.newState()
+	}
+
+	var v any
+	if final {
+		v = agg.Final()
+	} else {
+		v = agg.(WindowAggregator).Value()
+	}
+
+	if err, ok := v.(error); ok {
+		resultError(ctx, fmt.Errorf("%s: %w", a.name, err))
+		return
+	}
+	if err := setResult(ctx, v); err != nil {
+		resultError(ctx, fmt.Errorf("%s: %w", a.name, err))
+	}
+}
+
+// recover makes a panic of the aggregate the error
+// of the SQL statement.
+func (a *goAggregate) recover(ctx *C.sqlite3_context) {
+	if r := recover(); r != nil {
+		resultError(ctx, fmt.Errorf("%s: panic: %v", a.name, r))
+	}
+}
+
+// RegisterStatAggregates registers these aggregate (and window)
+// functions; NULLs are skipped, and the result of no values is NULL:
+//
+//   - median(x): the median of x.
+//   - percentile(x, p): the p-th percentile (0 to 100) of x, with
+//     linear interpolation between the closest values; p must be
+//     the same for all rows of a group.
+//   - weighted_avg(x, w): the average of x weighted by w.
+//
+// e.g.
+//
+//	db.RegisterStatAggregates()
+//	dt, err := db.GetDataTable(`select dept, median(salary), percentile(salary, 90)
+//		from employee group by dept`)
+func (d *DB) RegisterStatAggregates() error {
+	aggs := map[string]func() Aggregator{
+		"median":       func() Aggregator { return &percentileAgg{p: 50, median: true} },
+		"percentile":   func() Aggregator { return &percentileAgg{p: -1} },
+		"weighted_avg": func() Aggregator { return &weightedAvgAgg{} },
+	}
+
+	for _, name := range []string{"median", "percentile", "weighted_avg"} {
+		if err := d.RegisterAggregate(name, aggs[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// percentileAgg is median() and percentile(); see RegisterStatAggregates().
+type percentileAgg struct {
+	p      float64 // -1 until the first row of percentile()
+	median bool
+	values []float64
+}
+
+func (a *percentileAgg) Step(args ...any) error {
+	x, ok, err := a.args(args)
+	if err != nil || !ok {
+		return err
+	}
+	a.values = append(a.values, x)
+
+	return nil
+}
+
+func (a *percentileAgg) Inverse(args ...any) error {
+	x, ok, err := a.args(args)
+	if err != nil || !ok {
+		return err
+	}
+	if i := slices.Index(a.values, x); i >= 0 {
+		a.values = slices.Delete(a.values, i, i+1)
+	}
+
+	return nil
+}
+
+// args returns the x of a row; ok is false for NULL.
+func (a *percentileAgg) args(args []any) (x float64, ok bool, err error) {
+	want := 2
+	if a.median {
+		want = 1
+	}
+	if len(args) != want {
+		return 0, false, fmt.Errorf("%d args expected, %d given", want, len(args))
+	}
+
+	if !a.median {
+		p, isNum, err := aggFloat(args[1])
+		if err != nil || !isNum || p < 0 || p > 100 {
+			return 0, false, errors.New("the percentile must be a number between 0 and 100")
+		}
+		if a.p < 0 {
+			a.p = p
+		} else if a.p != p {
+			return 0, false, errors.New("the percentile must be the same for all rows")
+		}
+	}
+
+	return aggFloat(args[0])
+}
+
+func (a *percentileAgg) Value() any {
+	if len(a.values) == 0 {
+		return nil
+	}
+
+	v := slices.Clone(a.values)
+	slices.Sort(v)
+
+	// linear interpolation between the closest ranks
+	ix := a.p / 100 * float64(len(v)-1)
+	i := int(math.Floor(ix))
+	if i+1 >= len(v) {
+		return v[len(v)-1]
+	}
+
+	return v[i] + (v[i+1]-v[i])*(ix-float64(i))
+}
+
+func (a *percentileAgg) Final() any {
+	return a.Value()
+}
+
+// weightedAvgAgg is weighted_avg(); see RegisterStatAggregates().
+type weightedAvgAgg struct {
+	sum     float64 // of x * w
+	weights float64
+	n       int
+}
+
+func (a *weightedAvgAgg) Step(args ...any) error {
+	return a.add(args, 1)
+}
+
+func (a *weightedAvgAgg) Inverse(args ...any) error {
+	return a.add(args, -1)
+}
+
+// add adds (or removes, if sign is -1) the x and w of a row.
+func (a *weightedAvgAgg) add(args []any, sign float64) error {
+	if len(args) != 2 {
+		return fmt.Errorf("2 args expected, %d given", len(args))
+	}
+
+	x, okX, err := aggFloat(args[0])
+	if err != nil {
+		return err
+	}
+	w, okW, err := aggFloat(args[1])
+	if err != nil {
+		return err
+	}
+	if !okX || !okW {
+		return nil
+	}
+
+	a.sum += sign * x * w
+	a.weights += sign * w
+	a.n += int(sign)
+
+	return nil
+}
+
+func (a *weightedAvgAgg) Value() any {
+	if a.n == 0 {
+		return nil
+	}
+	if a.weights == 0 {
+		return errors.New("the sum of the weights is zero")
+	}
+
+	return a.sum / a.weights
+}
+
+func (a *weightedAvgAgg) Final() any {
+	return a.Value()
+}
+
+// aggFloat converts an arg of an aggregate to float64; ok is
+// false for NULL.
+func aggFloat(v any) (f float64, ok bool, err error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, false, nil
+	case int64:
+		return float64(v), true, nil
+	case float64:
+		return v, true, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("%q is not a number", v)
+		}
+		return f, true, nil
+	}
+
+	return 0, false, fmt.Errorf("a %s is not a number", GetSQLiteDataType(v))
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// countAgg is count(x) of the non-NULL x; an error for 'bad'.
+type countAgg struct{ n int64 }
+
+func (a *countAgg) Step(args ...any) error {
+	if args[0] == "bad" {
+		return errors.New("bad value")
+	}
+	if args[0] != nil {
+		a.n++
+	}
+	return nil
+}
+
+func (a *countAgg) Final() any { return a.n }
+
+func openScores(t *testing.T) *DB {
+	t.Helper()
+
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table score(id integer primary key, grp text, x real, w real)")
+	for _, r := range [][]any{
+		{"a", 1, 1}, {"a", 2, 1}, {"a", 3, 2}, {"a", 10, 0},
+		{"b", 5, 1}, {"b", nil, 1},
+	} {
+		mustExec(t, db, "insert into score(grp, x, w) values(?, ?, ?)", r...)
+	}
+
+	if err := db.RegisterStatAggregates(); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestRegisterAggregate(t *testing.T) {
+	db := openScores(t)
+
+	if err := db.RegisterAggregate("count_x", func() Aggregator { return &countAgg{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := QueryAll[int64](db, "select count_x(x) from score group by grp order by grp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int64{4, 1}) {
+		t.Fatalf("got %v, want [4 1]", got)
+	}
+
+	// one state per group; a new one for every query
+	if n := count(t, db, "select count_x(x) from score"); n != 5 {
+		t.Fatalf("got %d, want 5", n)
+	}
+
+	_, err = QueryOne[int64](db, "select count_x('bad')")
+	if err == nil || !strings.Contains(err.Error(), "count_x: bad value") {
+		t.Fatalf("got %v, want the error of Step()", err)
+	}
+
+	if err := db.RegisterAggregate("", func() Aggregator { return &countAgg{} }); err == nil {
+		t.Fatal("no error for an empty name")
+	}
+	if err := db.RegisterAggregate("f", func() Aggregator { return nil }); err == nil {
+		t.Fatal("no error for a nil state")
+	}
+}
+
+func TestStatAggregates(t *testing.T) {
+	db := openScores(t)
+
+	tests := []struct {
+		sqlx string
+		want any
+	}{
+		{"select median(x) from score where grp = 'a'", 2.5},
+		{"select median(x) from score where grp = 'b'", 5.0},
+		{"select percentile(x, 0) from score where grp = 'a'", 1.0},
+		{"select percentile(x, 100) from score where grp = 'a'", 10.0},
+		{"select percentile(x, 50) from score where grp = 'a'", 2.5},
+		{"select weighted_avg(x, w) from score where grp = 'a'", 2.25},
+		{"select median(x) from score where grp = 'none'", nil},
+	}
+	for _, tt := range tests {
+		dt, err := db.GetDataTable(tt.sqlx)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sqlx, err)
+		}
+		var got any
+		for _, v := range dt.Rows[0] {
+			got = v
+		}
+		if got != tt.want {
+			t.Fatalf("%s: got %v, want %v", tt.sqlx, got, tt.want)
+		}
+	}
+
+	for _, sqlx := range []string{
+		"select percentile(x, 101) from score",
+		"select percentile(x, id) from score",
+		"select median(x, 1) from score",
+		"select weighted_avg(x, 0) from score",
+	} {
+		if _, err := db.GetDataTable(sqlx); err == nil {
+			t.Fatalf("%s: no error", sqlx)
+		}
+	}
+}
+
+func TestWindowAggregate(t *testing.T) {
+	db := openScores(t)
+
+	// a moving median of 3 rows
+	got, err := QueryAll[float64](db, `select median(x) over (order by id rows between 2 preceding and current row)
+		from score where grp = 'a' order by id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []float64{1, 1.5, 2, 3}) {
+		t.Fatalf("got %v, want [1 1.5 2 3]", got)
+	}
+
+	got, err = QueryAll[float64](db, `select weighted_avg(x, w) over (order by id rows between 1 preceding and current row)
+		from score where grp = 'a' order by id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []float64{1, 1.5, 8.0 / 3, 3}) {
+		t.Fatalf("got %v", got)
+	}
+
+	// not a window function
+	if err := db.RegisterAggregate("count_x", func() Aggregator { return &countAgg{} }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueryAll[int64](db, "select count_x(x) over (order by id) from score"); err == nil {
+		t.Fatal("no error for an aggregate that is not a window function")
+	}
+}
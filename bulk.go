@@ -0,0 +1,244 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// ConflictMode is what BulkInsert() does with a row that violates
+// a UNIQUE, PRIMARY KEY or NOT NULL constraint.
+// See: https://www.sqlite.org/lang_conflict.html
+type ConflictMode int
+
+const (
+	// ConflictAbort stops the bulk insert with the error; the
+	// rows of the current batch are rolled back.
+	ConflictAbort ConflictMode = iota
+
+	// ConflictIgnore skips the row (INSERT OR IGNORE).
+	ConflictIgnore
+
+	// ConflictReplace deletes the existing row(s) that conflict
+	// and inserts the row (INSERT OR REPLACE).
+	ConflictReplace
+)
+
+func (m ConflictMode) String() string {
+	switch m {
+	case ConflictAbort:
+		return "ABORT"
+	case ConflictIgnore:
+		return "IGNORE"
+	case ConflictReplace:
+		return "REPLACE"
+	}
+
+	return fmt.Sprintf("ConflictMode(%d)", int(m))
+}
+
+// defaultBulkBatchSize is the number of rows per
+// transaction of BulkInsert(), if not set.
+const defaultBulkBatchSize = 1000
+
+// BulkOptions are the options of DB.BulkInsert().
+type BulkOptions struct {
+	// BatchSize is the number of rows inserted per transaction;
+	// 0 means 1000.
+	BatchSize int
+
+	OnConflict ConflictMode
+
+	// Schema is the (attached) database of the table;
+	// e.g. "main". Empty means unqualified.
+	Schema string
+
+	// Progress is called after every committed batch with the
+	// total number of rows inserted and skipped (ConflictIgnore).
+	Progress func(rowsInserted int64, rowsSkipped int64)
+}
+
+// BulkInsert inserts rows into the columns of a table; the insert
+// statement is compiled once, and the rows are inserted in batches,
+// one transaction per batch. It returns the number of rows inserted;
+// on error (or when ctx is done) the current batch is rolled back,
+// the batches before it stay committed. Other writes wait until the
+// bulk insert is done; so rows must not write to the database. e.g.
+//
+//	rows := func(yield func([]any) bool) {
+//		for i := range 1_000_000 {
+//			if !yield([]any{i, fmt.Sprintf("name-%d", i)}) {
+//				return
+//			}
+//		}
+//	}
+//	n, err := db.BulkInsert(ctx, "person", []string{"id", "name"}, rows,
+//		gosqlite.BulkOptions{BatchSize: 5000, OnConflict: gosqlite.ConflictIgnore})
+func (d *DB) BulkInsert(ctx context.Context, table string, columns []string, rows iter.Seq[[]any], opts BulkOptions) (int64, error) {
+	if d == nil || d.Closed {
+		return 0, errors.New("database is not open")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if table == "" {
+		return 0, errors.New("table name is empty")
+	}
+	if len(columns) == 0 {
+		return 0, errors.New("no columns to insert")
+	}
+	if rows == nil {
+		return 0, errors.New("rows is nil")
+	}
+	if opts.BatchSize < 0 {
+		return 0, errors.New("BatchSize cannot be negative")
+	}
+
+	sqlx, err := bulkInsertSQL(opts.Schema, table, columns, opts.OnConflict)
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBulkBatchSize
+	}
+
+	// other writes wait for the bulk insert; see doExec().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	mu := d.connLock()
+	mu.Lock()
+	s, _, err := d.Prepare(sqlx, nil)
+	mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	var inserted, skipped int64
+	var batchInserted, batchSkipped int64
+	inTx := false
+	n := 0
+
+	commit := func() error {
+		// a locked database is not retried in a transaction
+		// (see retry()); the batch is rolled back.
+		res := d.execDo(context.Background(), "COMMIT")
+		if res.Error() != nil {
+			return res.Error()
+		}
+		inTx = false
+		n = 0
+
+		inserted += batchInserted
+		skipped += batchSkipped
+		batchInserted, batchSkipped = 0, 0
+
+		if opts.Progress != nil {
+			opts.Progress(inserted, skipped)
+		}
+
+		return nil
+	}
+
+	rowNo := 0
+	for row := range rows {
+		rowNo++
+
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if len(row) != len(columns) {
+			err = fmt.Errorf("row %d has %d values; %d expected", rowNo, len(row), len(columns))
+			break
+		}
+
+		if !inTx {
+			res := d.execDo(ctx, "BEGIN IMMEDIATE")
+			if err = res.Error(); err != nil {
+				break
+			}
+			inTx = true
+		}
+
+		if err = s.Bind(row...); err != nil {
+			break
+		}
+		res := s.execDo(ctx)
+		if err = res.Error(); err != nil {
+			err = fmt.Errorf("row %d: %w", rowNo, err)
+			break
+		}
+		if res.rowsAffected > 0 {
+			batchInserted++
+		} else {
+			batchSkipped++
+		}
+
+		n++
+		if n >= batchSize {
+			if err = commit(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err == nil && inTx {
+		err = commit()
+	}
+
+	if err != nil {
+		if inTx && !d.AutoCommit() {
+			// not canceled by ctx.
+			d.execDo(context.Background(), "ROLLBACK")
+		}
+		return inserted, err
+	}
+
+	return inserted, nil
+}
+
+// bulkInsertSQL is the insert statement of BulkInsert().
+func bulkInsertSQL(schema string, table string, columns []string, onConflict ConflictMode) (string, error) {
+	verb := "INSERT"
+	switch onConflict {
+	case ConflictAbort:
+	case ConflictIgnore:
+		verb = "INSERT OR IGNORE"
+	case ConflictReplace:
+		verb = "INSERT OR REPLACE"
+	default:
+		return "", fmt.Errorf("invalid on-conflict mode %s", onConflict)
+	}
+
+	target := quoteIdent(table)
+	if schema != "" {
+		target = quoteIdent(schema) + "." + target
+	}
+
+	cols := make([]string, len(columns))
+	for i := range columns {
+		if columns[i] == "" {
+			return "", fmt.Errorf("column %d has no name", i+1)
+		}
+		cols[i] = quoteIdent(columns[i])
+	}
+
+	return fmt.Sprintf("%s INTO %s(%s) VALUES(%s)", verb, target,
+		strings.Join(cols, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")), nil
+}
+
+// quoteIdent quotes an identifier (e.g. a table or a column name).
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
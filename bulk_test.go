@@ -0,0 +1,118 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// seqRows returns the rows (i, "name i") for i in [from, to).
+func seqRows(from, to int) func(yield func([]any) bool) {
+	return func(yield func([]any) bool) {
+		for i := from; i < to; i++ {
+			if !yield([]any{i, "name"}) {
+				return
+			}
+		}
+	}
+}
+
+func TestBulkInsert(t *testing.T) {
+	db := openTestDB(t, true)
+	mustExec(t, db, "create table t(id integer primary key, name text)")
+
+	batches := 0
+	var last int64
+	n, err := db.BulkInsert(context.Background(), "t", []string{"id", "name"}, seqRows(0, 2500), BulkOptions{
+		BatchSize: 1000,
+		Progress: func(inserted, skipped int64) {
+			batches++
+			last = inserted
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2500 || last != 2500 || batches != 3 {
+		t.Fatalf("got %d rows (%d in progress) in %d batches", n, last, batches)
+	}
+	if c := count(t, db, "select count(*) from t"); c != 2500 {
+		t.Fatalf("got %d rows, want 2500", c)
+	}
+}
+
+func TestBulkInsertConflict(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(id integer primary key, name text)")
+	mustExec(t, db, "insert into t values(5, 'x')")
+
+	var skipped int64
+	n, err := db.BulkInsert(context.Background(), "t", []string{"id", "name"}, seqRows(0, 10), BulkOptions{
+		OnConflict: ConflictIgnore,
+		Progress:   func(_, s int64) { skipped = s },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 9 || skipped != 1 {
+		t.Fatalf("got %d rows and %d skipped", n, skipped)
+	}
+
+	// abort: the batch is rolled back
+	n, err = db.BulkInsert(context.Background(), "t", []string{"id", "name"}, seqRows(9, 20), BulkOptions{})
+	if !errors.Is(err, ErrConstraint) {
+		t.Fatalf("got %v, want a constraint error", err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d rows committed, want 0", n)
+	}
+	if c := count(t, db, "select count(*) from t"); c != 10 {
+		t.Fatalf("got %d rows, want 10", c)
+	}
+}
+
+// TestCopyTableToDatabase copies a table by BulkInsert(), on a
+// database that is not in WAL mode.
+func TestCopyTableToDatabase(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(id integer primary key, name text)")
+	mustExec(t, db, "insert into t values(1, 'a'), (2, 'b'), (3, 'c')")
+
+	target := filepath.Join(t.TempDir(), "target.db")
+	trgt, err := OpenWith(target, WithCreate(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	trgt.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.CopyTableToDatabase("t", target, true, false, false)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CopyTableToDatabase did not return")
+	}
+
+	trgt, err = OpenWith(target, WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trgt.Close()
+	if c := count(t, trgt, "select count(*) from t"); c != 3 {
+		t.Fatalf("got %d rows, want 3", c)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+// #include <stdint.h>
+// #include "sqlite3.h"
+// int go_xBusy(uintptr_t h, int count);
+// static int busyHandler(void *h, int count){
+// 	return go_xBusy((uintptr_t)h, count);
+// }
+// static int set_busy_handler(sqlite3 *db, uintptr_t h){
+// 	return sqlite3_busy_handler(db, busyHandler, (void*)h);
+// }
+import "C"
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"runtime/cgo"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy is how a write (Exec(), Execute(), Stmt.Exec(), ...) is
+// retried when the database is locked by another connection or
+// process (SQLITE_BUSY). A statement in a transaction is not retried;
+// WithTx() runs the whole transaction again instead. See
+// DB.SetRetryPolicy().
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a statement is run,
+	// incl. the first one; 1 means no retry.
+	MaxAttempts int
+
+	// Backoff is the time to wait before the first retry; it
+	// doubles on every retry, up to MaxBackoff (0 means no limit).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Jitter randomizes every wait by up to +/- this
+	// fraction of it (0 to 1); e.g. 0.2 is +/- 20%.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the retry policy of a newly opened database.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	Backoff:     150 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+	Jitter:      0.2,
+}
+
+// busyState holds the lock handling settings of a DB; they are
+// shared with the readers of the pool (see applyBusy()).
+type busyState struct {
+	mu      sync.Mutex
+	policy  RetryPolicy
+	timeout time.Duration // of SetBusyTimeout()
+
+	// fn is the func of SetBusyHandler(); nil if not set. It is
+	// called via handle (of the busyState), so that a reader can
+	// use the handler while it is replaced. See go_xBusy().
+	fn     atomic.Pointer[func(count int) bool]
+	handle cgo.Handle
+
+	// gen is incremented when the timeout or the handler is set.
+	gen uint64
+}
+
+func newBusyState() *busyState {
+	return &busyState{policy: DefaultRetryPolicy}
+}
+
+// SetRetryPolicy sets how writes are retried when the database
+// is locked (SQLITE_BUSY); see DefaultRetryPolicy.
+func (d *DB) SetRetryPolicy(p RetryPolicy) error {
+	if p.MaxAttempts < 1 {
+		return errors.New("MaxAttempts must be at least 1")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("Backoff and MaxBackoff cannot be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("Jitter must be between 0 and 1")
+	}
+
+	d.busy.mu.Lock()
+	d.busy.policy = p
+	d.busy.mu.Unlock()
+
+	return nil
+}
+
+// RetryPolicy returns the retry policy of the database.
+func (d *DB) RetryPolicy() RetryPolicy {
+	if d.busy == nil {
+		return DefaultRetryPolicy
+	}
+
+	d.busy.mu.Lock()
+	defer d.busy.mu.Unlock()
+
+	return d.busy.policy
+}
+
+// SetBusyTimeout makes sqlite3 wait (sleep) up to t for a lock
+// held by another connection, before returning SQLITE_BUSY; 0
+// turns it off. It replaces the handler of SetBusyHandler(), if any.
+// It applies to the writer and to the readers of the pool (a reader
+// in use gets it when it is used next).
+// See: https://www.sqlite.org/c3ref/busy_timeout.html
+func (d *DB) SetBusyTimeout(t time.Duration) error {
+	if d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+	if t < 0 {
+		return errors.New("busy timeout cannot be negative")
+	}
+
+	b := d.busy
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.fn.Store(nil)
+	b.timeout = t
+	b.gen++
+
+	return b.apply(d.DBHwnd)
+}
+
+// SetBusyHandler sets a func that sqlite3 calls when a lock is held
+// by another connection; count is the number of times it has been
+// called for the same lock. It returns true to try again, or false
+// to give up (SQLITE_BUSY). nil removes the handler. It replaces
+// the timeout of SetBusyTimeout(), if any. It applies to the writer
+// and to the readers of the pool, as SetBusyTimeout() does; so fn
+// can be called concurrently. fn must not use the database.
+// See: https://www.sqlite.org/c3ref/busy_handler.html
+func (d *DB) SetBusyHandler(fn func(count int) bool) error {
+	if d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+
+	b := d.busy
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if fn == nil {
+		b.fn.Store(nil)
+	} else {
+		b.fn.Store(&fn)
+		if b.handle == 0 {
+			b.handle = cgo.NewHandle(b)
+		}
+	}
+	b.timeout = 0
+	b.gen++
+
+	return b.apply(d.DBHwnd)
+}
+
+// apply sets the timeout or the handler on a connection;
+// the caller must hold the lock of b.
+func (b *busyState) apply(hwnd *C.sqlite3) error {
+	var rc C.int
+	if b.fn.Load() != nil {
+		rc = C.set_busy_handler(hwnd, C.uintptr_t(b.handle))
+	} else {
+		// also removes the handler, if any
+		rc = C.sqlite3_busy_timeout(hwnd, C.int(b.timeout.Milliseconds()))
+	}
+
+	return getSQLiteErr(rc, hwnd)
+}
+
+// handler is the func of SetBusyHandler(); nil if not set.
+func (b *busyState) handler() func(count int) bool {
+	if fn := b.fn.Load(); fn != nil {
+		return *fn
+	}
+
+	return nil
+}
+
+// releaseHandler deletes the handle of b; the connections
+// are closed. The caller must hold the lock of b.
+func (b *busyState) releaseHandler() {
+	b.fn.Store(nil)
+	if b.handle != 0 {
+		b.handle.Delete()
+		b.handle = 0
+	}
+}
+
+// retry calls fn until it succeeds, fails with an error other than
+// SQLITE_BUSY, ctx is done, or the attempts of the retry policy
+// are used up; it returns the last error of fn. The caller holds the
+// lock of the connection, also while waiting; so the connection is not
+// used by another statement in between. A statement in a transaction
+// is not retried (the transaction may no longer be the same); see
+// WithTx().
+func (d *DB) retry(ctx context.Context, fn func() error) error {
+	p := d.RetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !errors.Is(err, ErrBusy) || attempt >= p.MaxAttempts {
+			return err
+		}
+		if d.DBHwnd == nil || C.sqlite3_get_autocommit(d.DBHwnd) == 0 {
+			return err
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return err
+		}
+
+		if !sleepCtx(ctx, p.wait(attempt)) {
+			return err
+		}
+	}
+}
+
+// sleepCtx sleeps for t; false if ctx is done first.
+func sleepCtx(ctx context.Context, t time.Duration) bool {
+	timer := time.NewTimer(t)
+	defer timer.Stop()
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// wait is the time to wait after the attempt-th attempt.
+func (p RetryPolicy) wait(attempt int) time.Duration {
+	limit := p.MaxBackoff
+	if limit == 0 {
+		limit = math.MaxInt64 / 2
+	}
+
+	w := p.Backoff
+	for i := 1; i < attempt && w < limit; i++ {
+		w *= 2
+	}
+	w = min(w, limit)
+
+	if p.Jitter > 0 {
+		w += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(w))
+	}
+
+	return w
+}
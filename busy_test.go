@@ -0,0 +1,116 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lockDB opens another connection to the database of db and holds
+// an exclusive lock of it; unlock releases the lock.
+func lockDB(t *testing.T, db *DB) (unlock func()) {
+	t.Helper()
+
+	other, err := OpenWith(db.FilePath(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, other, "BEGIN EXCLUSIVE")
+
+	// called by time.AfterFunc(); so no t.Fatal()
+	return func() {
+		res := other.Exec("COMMIT")
+		if err := res.Error(); err != nil {
+			t.Error(err)
+		}
+		other.Close()
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	// gives up after MaxAttempts
+	db.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond})
+	unlock := lockDB(t, db)
+
+	start := time.Now()
+	res := db.Exec("insert into t values(1)")
+	if !errors.Is(res.Error(), ErrBusy) {
+		t.Fatalf("got %v, want ErrBusy", res.Error())
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Fatalf("gave up after %v; not retried", d)
+	}
+
+	// succeeds once the lock is released
+	db.SetRetryPolicy(RetryPolicy{MaxAttempts: 20, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	time.AfterFunc(50*time.Millisecond, unlock)
+
+	res = db.Exec("insert into t values(1)")
+	if res.Error() != nil {
+		t.Fatal(res.Error())
+	}
+}
+
+func TestRetryNotInTx(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	db.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond})
+
+	tx, err := db.BeginTx(context.Background(), TxOptions{Mode: Deferred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	unlock := lockDB(t, db)
+	defer unlock()
+
+	start := time.Now()
+	res := tx.Exec("insert into t values(1)")
+	if !errors.Is(res.Error(), ErrBusy) {
+		t.Fatalf("got %v, want ErrBusy", res.Error())
+	}
+	if d := time.Since(start); d >= 100*time.Millisecond {
+		t.Fatalf("a statement in a transaction was retried (%v)", d)
+	}
+}
+
+func TestBusyHandler(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	db.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+	unlock := lockDB(t, db)
+
+	var calls atomic.Int32
+	db.SetBusyHandler(func(count int) bool {
+		calls.Add(1)
+		return count < 2
+	})
+	res := db.Exec("insert into t values(1)")
+	if !errors.Is(res.Error(), ErrBusy) {
+		t.Fatalf("got %v, want ErrBusy", res.Error())
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("the handler was called %d times, want 3", n)
+	}
+
+	// sqlite3 waits for the lock
+	db.SetBusyTimeout(5 * time.Second)
+	time.AfterFunc(50*time.Millisecond, unlock)
+
+	res = db.Exec("insert into t values(1)")
+	if res.Error() != nil {
+		t.Fatal(res.Error())
+	}
+}
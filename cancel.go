@@ -0,0 +1,56 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+// #include <stdint.h>
+// #include "sqlite3.h"
+// int go_xStmtProgress(uintptr_t h);
+// static int stmtProgress(void *h){
+// 	return go_xStmtProgress((uintptr_t)h);
+// }
+// static void set_stmt_progress_handler(sqlite3 *db, int n, uintptr_t h){
+// 	sqlite3_progress_handler(db, n, stmtProgress, (void*)h);
+// }
+import "C"
+import (
+	"context"
+	"runtime/cgo"
+)
+
+// progressOpCount is the number of virtual machine instructions
+// between the checks of the context of a running statement.
+const progressOpCount = 1000
+
+// watchContext installs a progress handler on the connection that
+// aborts the running statement (with SQLITE_INTERRUPT) once ctx is
+// done; the returned func removes the handler. The caller must hold
+// the lock of the connection while the statement is running; other
+// statements are not affected (unlike DB.Interrupt()).
+// See: https://www.sqlite.org/c3ref/progress_handler.html
+func (d *DB) watchContext(ctx context.Context) (stop func()) {
+	if ctx == nil || ctx.Done() == nil {
+		// never canceled
+		return func() {}
+	}
+
+	h := cgo.NewHandle(ctx)
+	C.set_stmt_progress_handler(d.DBHwnd, progressOpCount, C.uintptr_t(h))
+
+	return func() {
+		C.sqlite3_progress_handler(d.DBHwnd, 0, nil, nil)
+		h.Delete()
+	}
+}
+
+// stepErr is the error of a failed sqlite3_step() of sqlx; the
+// error of ctx, if the statement was aborted by watchContext().
+func (d *DB) stepErr(ctx context.Context, rc C.int, sqlx string) error {
+	if rc == SQLITE_INTERRUPT && ctx != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return withSQL(getSQLiteErr(rc, d.DBHwnd), sqlx)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowQuery runs for a long time before its first (and only) row.
+const slowQuery = `with recursive c(x) as (select 1 union all select x + 1 from c)
+	select x from c where x = -1`
+
+func TestCancelQuery(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := db.GetDataTableWithContext(ctx, slowQuery)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("the query was aborted after %v", d)
+	}
+
+	// the connection is usable
+	mustExec(t, db, "insert into t values(1)")
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+}
+
+func TestCancelExec(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	res := db.ExecWithContext(ctx, `insert into t
+		with recursive c(x) as (select 1 union all select x + 1 from c)
+		select x from c where x = -1`)
+	if err := res.Error(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	// a done context fails right away
+	res = db.ExecWithContext(ctx, "insert into t values(1)")
+	if err := res.Error(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	mustExec(t, db, "insert into t values(2)")
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+}
+
+func TestCancelRows(t *testing.T) {
+	db := openTestDB(t, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the statement runs in Next()
+	rows, err := db.QueryWithContext(ctx, slowQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Fatal("got a row")
+	}
+	if !errors.Is(rows.Err(), context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", rows.Err())
+	}
+	rows.Close()
+
+	// the other statements are not affected
+	if n := count(t, db, "select 1"); n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+
+	rows, err = db.Query("select 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("no row")
+	}
+	var n int64
+	if err := rows.ScanWithContext(ctx, &n); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if err := rows.ScanWithContext(context.Background(), &n); err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1", n, err)
+	}
+}
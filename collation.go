@@ -0,0 +1,290 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+// int go_sqlite3_collation(uintptr_t h, int na, const void *a, int nb, const void *b);
+// void go_sqlite3_destroy(uintptr_t h);
+// static int collation_cmp(void *h, int na, const void *a, int nb, const void *b){
+//   return go_sqlite3_collation((uintptr_t)h, na, a, nb, b);
+// }
+// static void collation_destroy(void *h){
+//   go_sqlite3_destroy((uintptr_t)h);
+// }
+// static int create_collation(sqlite3 *db, const char *name, uintptr_t h){
+//   return sqlite3_create_collation_v2(db, name, SQLITE_UTF8, (void*)h, collation_cmp, collation_destroy);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"runtime/cgo"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// The names of the collations of RegisterUnicodeCollations(). The
+// natural sort is NATSORT, since NATURAL is a keyword of SQL (i.e.
+// "collate natural" is a syntax error).
+const (
+	CollationUnicodeNoCase = "UNICODE_NOCASE"
+	CollationNatural       = "NATSORT"
+	CollationNoAccent      = "NOACCENT"
+)
+
+// goCollation is a collation; see RegisterCollation().
+type goCollation struct {
+	cmp func(a, b string) int
+}
+
+// RegisterCollation registers a collating sequence; cmp returns a
+// negative number if a < b, zero if a == b and a positive number if
+// a > b, and it must be consistent (e.g. if a < b, then b > a). The
+// collation can then be used in COLLATE clauses, in indexes and in
+// the sortOrder of GetPage(). e.g.
+//
+//	err := db.RegisterCollation("LENGTH", func(a, b string) int {
+//		return len(a) - len(b)
+//	})
+//	...
+//	rows, err := db.Query("select name from person order by name collate LENGTH")
+//
+// As with RegisterFunc(), the collation is registered on all connections
+// of the database, so cmp must be safe for concurrent use; a collation
+// with the same name is replaced.
+// See: https://www.sqlite.org/c3ref/create_collation.html
+func (d *DB) RegisterCollation(name string, cmp func(a, b string) int) error {
+	if d == nil || d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+	if name == "" {
+		return errors.New("collation name is empty")
+	}
+	if cmp == nil {
+		return fmt.Errorf("%s: cmp is nil", name)
+	}
+
+	c := goCollation{cmp: cmp}
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return d.registry.add(d.DBHwnd, "collation/"+name, func(hwnd *C.sqlite3) error {
+		cName := C.CString(name)
+		defer C.free(unsafe.Pointer(cName))
+
+		// h is deleted by sqlite3, incl. on error
+		h := cgo.NewHandle(&c)
+
+		rc := C.create_collation(hwnd, cName, C.uintptr_t(h))
+		if rc != SQLITE_OK {
+			return getSQLiteErr(rc, hwnd)
+		}
+
+		return nil
+	})
+}
+
+// compare calls the cmp of the collation; a panic of cmp is
+// recovered, and the strings are compared as by BINARY.
+func (c *goCollation) compare(a, b string) (n int) {
+	defer func() {
+		if r := recover(); r != nil {
+			n = strings.Compare(a, b)
+		}
+	}()
+
+	return c.cmp(a, b)
+}
+
+// RegisterUnicodeCollations registers these collations:
+//
+//   - UNICODE_NOCASE: case-insensitive for all of Unicode (the NOCASE
+//     of sqlite3 is for ASCII only); see CompareNoCase().
+//   - NATSORT: numbers are compared by their value; i.e. "file9" is
+//     before "file10". See CompareNatural().
+//   - NOACCENT: accent-insensitive, independent of the locale; i.e.
+//     "é" is "e". It is case-sensitive ("File2" is before "eclair");
+//     see CompareNoAccent().
+//
+// e.g.
+//
+//	db.RegisterUnicodeCollations()
+//	rows, err := db.Query("select name from person order by name collate UNICODE_NOCASE")
+func (d *DB) RegisterUnicodeCollations() error {
+	collations := []struct {
+		name string
+		cmp  func(a, b string) int
+	}{
+		{CollationUnicodeNoCase, CompareNoCase},
+		{CollationNatural, CompareNatural},
+		{CollationNoAccent, CompareNoAccent},
+	}
+
+	for _, c := range collations {
+		if err := d.RegisterCollation(c.name, c.cmp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CompareNoCase compares two strings with Unicode simple case
+// folding; e.g. "Ä" and "ä" are equal.
+func CompareNoCase(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+
+		if n := compareRune(foldRune(ra), foldRune(rb)); n != 0 {
+			return n
+		}
+		a, b = a[na:], b[nb:]
+	}
+
+	return len(a) - len(b)
+}
+
+// foldRune is the smallest rune that is equal to r under
+// simple case folding; e.g. 'A' for 'a'.
+func foldRune(r rune) rune {
+	m := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		m = min(m, f)
+	}
+
+	return m
+}
+
+func compareRune(a, b rune) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// CompareNatural compares two strings, where the runs of digits
+// are compared by their value; e.g. "file9" < "file10". The other
+// runes are compared as by CompareNoCase(). Numbers of the same
+// value are ordered by their leading zeros; e.g. "1" < "01".
+func CompareNatural(a, b string) int {
+	zeros := 0
+
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitRun(a), digitRun(b)
+			a, b = a[len(da):], b[len(db):]
+
+			// by value, then by the leading zeros
+			ta, tb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(ta) != len(tb) {
+				return len(ta) - len(tb)
+			}
+			if n := strings.Compare(ta, tb); n != 0 {
+				return n
+			}
+			if zeros == 0 {
+				zeros = len(da) - len(db)
+			}
+			continue
+		}
+
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if n := compareRune(foldRune(ra), foldRune(rb)); n != 0 {
+			return n
+		}
+		a, b = a[na:], b[nb:]
+	}
+
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+
+	return zeros
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// digitRun is the leading digits of s.
+func digitRun(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i]
+}
+
+// CompareNoAccent compares two strings without their accents
+// (diacritics); e.g. "é" is "e", "ø" is "o" and "æ" is "ae". The
+// accented Latin letters are replaced by their base letters, and
+// combining marks (e.g. of decomposed text) are ignored. The case
+// is not folded; i.e. "É" is "E", but not "e", and "File2" is
+// before "eclair" (as by BINARY).
+func CompareNoAccent(a, b string) int {
+	return strings.Compare(removeAccents(a), removeAccents(b))
+}
+
+// The base letters of the accented Latin letters of Latin-1 and
+// Latin Extended-A; the letters of accented are replaced by the
+// ones of unaccented, at the same position.
+const (
+	accented   = "ÀÁÂÃÄÅÇÈÉÊËÌÍÎÏÐÑÒÓÔÕÖØÙÚÛÜÝàáâãäåçèéêëìíîïðñòóôõöøùúûüýÿĀāĂăĄąĆćĈĉĊċČčĎďĐđĒēĔĕĖėĘęĚěĜĝĞğĠġĢģĤĥĦħĨĩĪīĬĭĮįİıĴĵĶķĹĺĻļĽľĿŀŁłŃńŅņŇňŌōŎŏŐőŔŕŖŗŘřŚśŜŝŞşŠšŢţŤťŦŧŨũŪūŬŭŮůŰűŲųŴŵŶŷŸŹźŻżŽžſ"
+	unaccented = "AAAAAACEEEEIIIIDNOOOOOOUUUUYaaaaaaceeeeiiiidnoooooouuuuyyAaAaAaCcCcCcCcDdDdEeEeEeEeEeGgGgGgGgHhHhIiIiIiIiIiJjKkLlLlLlLlLlNnNnNnOoOoOoRrRrRrSsSsSsSsTtTtTtUuUuUuUuUuUuWwYyYZzZzZzs"
+)
+
+// accentFold is the base letter(s) of an accented letter.
+var accentFold = func() map[rune]string {
+	m := map[rune]string{
+		'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'ß': "ss",
+		'Þ': "TH", 'þ': "th", 'Ĳ': "IJ", 'ĳ': "ij",
+	}
+
+	base := []rune(unaccented)
+	for i, r := range []rune(accented) {
+		m[r] = string(base[i])
+	}
+
+	return m
+}()
+
+// removeAccents replaces the accented letters of s by their base
+// letters; see CompareNoAccent().
+func removeAccents(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			sb.WriteByte(byte(r))
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			// a combining mark
+			continue
+		}
+		if base, ok := accentFold[r]; ok {
+			sb.WriteString(base)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
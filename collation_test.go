@@ -0,0 +1,101 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareFuncs(t *testing.T) {
+	tests := []struct {
+		cmp  func(a, b string) int
+		a, b string
+		want int
+	}{
+		{CompareNoCase, "Émile", "émile", 0},
+		{CompareNoCase, "ÀÖß", "àöß", 0},
+		{CompareNoCase, "a", "B", -1},
+		{CompareNatural, "file9", "file10", -1},
+		{CompareNatural, "file10", "File9", 1},
+		{CompareNatural, "1", "01", -1},
+		{CompareNatural, "a2b", "a2b", 0},
+		{CompareNoAccent, "éclair", "eclair", 0},
+		{CompareNoAccent, "Über", "Uber", 0},
+		{CompareNoAccent, "File2", "eclair", -1},
+	}
+
+	for _, tt := range tests {
+		got := tt.cmp(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Fatalf("compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if back := tt.cmp(tt.b, tt.a); (back < 0) != (got > 0) {
+			t.Fatalf("compare(%q, %q) = %d is not consistent with %d", tt.b, tt.a, back, got)
+		}
+	}
+}
+
+func TestRegisterCollation(t *testing.T) {
+	db := openTestDB(t, false)
+	if err := db.RegisterUnicodeCollations(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RegisterCollation("LENGTH", func(a, b string) int { return len(a) - len(b) }); err != nil {
+		t.Fatal(err)
+	}
+
+	mustExec(t, db, "create table f(name text)")
+	for _, name := range []string{"file10", "File9", "file1", "éa", "eb", "Ec"} {
+		mustExec(t, db, "insert into f values(?)", name)
+	}
+
+	tests := []struct {
+		sqlx string
+		want []string
+	}{
+		{"select name from f where name like 'f%' order by name collate NATSORT",
+			[]string{"file1", "File9", "file10"}},
+		{"select name from f where name not like 'f%' order by name collate NOACCENT",
+			[]string{"Ec", "éa", "eb"}},
+		{"select name from f where name not like 'f%' order by name collate UNICODE_NOCASE",
+			[]string{"eb", "Ec", "éa"}},
+		{"select name from f where name = 'EB' collate UNICODE_NOCASE", []string{"eb"}},
+		{"select name from f where name not like 'f%' order by name collate LENGTH, name", []string{"Ec", "eb", "éa"}},
+	}
+	for _, tt := range tests {
+		got, err := QueryAll[string](db, tt.sqlx)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sqlx, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.sqlx, got, tt.want)
+		}
+	}
+
+	// in an index
+	mustExec(t, db, "create index f_name on f(name collate NATSORT)")
+
+	// in the sort order of GetPage()
+	dt, err := db.GetPage(10, 1, "f", "file", "name", "collate NATSORT desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range dt.Rows {
+		got = append(got, r["name"].(string))
+	}
+	if !slices.Equal(got, []string{"file10", "File9", "file1"}) {
+		t.Fatalf("got %v", got)
+	}
+
+	if err := db.RegisterCollation("", CompareNoCase); err == nil {
+		t.Fatal("no error for an empty name")
+	}
+	if err := db.RegisterCollation("X", nil); err == nil {
+		t.Fatal("no error for a nil cmp")
+	}
+}
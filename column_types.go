@@ -0,0 +1,157 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+// The define makes sqlite3.h declare the column metadata interfaces
+// (sqlite3_column_table_name(), etc.); it does not enable them. The
+// sqlite3 library that is linked must itself be compiled with
+// SQLITE_ENABLE_COLUMN_METADATA.
+
+//#cgo CFLAGS: -DSQLITE_ENABLE_COLUMN_METADATA
+//#include <stdio.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// ColumnType describes a column of a result set. A column that is
+// an expression (e.g. count(*)) has no DeclType, DatabaseName,
+// TableName or OriginName. See: https://www.sqlite.org/c3ref/column_database_name.html
+type ColumnType struct {
+	// Name is the name of the column in the result set; i.e.
+	// its alias, if any.
+	Name string `json:"Name"`
+
+	// DeclType is the declared type of the column in its
+	// table; e.g. "VARCHAR(50)".
+	DeclType string `json:"DeclType"`
+
+	DatabaseName string `json:"DatabaseName"`
+	TableName    string `json:"TableName"`
+
+	// OriginName is the name of the column in its table.
+	OriginName string `json:"OriginName"`
+
+	CollSeq         string `json:"CollSeq"`
+	NotNULL         bool   `json:"NotNULL"`
+	IsPrimaryKey    bool   `json:"IsPrimaryKey"`
+	IsAutoIncrement bool   `json:"IsAutoIncrement"`
+	Ordinal         int    `json:"Ordinal"`
+}
+
+// ColumnTypes returns the metadata of the columns of the result
+// set; e.g. for a join or for an aliased column the source table
+// and column are included.
+func (rs *Rows) ColumnTypes() ([]ColumnType, error) {
+
+	if rs == nil || rs.stmt == nil || rs.stmt.cStmt == nil || rs.stmt.released || rs.closed {
+		return nil, errors.New("row is already closed")
+	}
+
+	mu := rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return rs.db.stmtColumnTypes(rs.stmt), nil
+}
+
+// stmtColumnTypes gets the metadata of the columns of a
+// prepared statement.
+func (d *DB) stmtColumnTypes(s *Stmt) []ColumnType {
+
+	colCnt := int(C.sqlite3_column_count(s.cStmt))
+	cols := make([]ColumnType, colCnt)
+
+	for i := range colCnt {
+		c := &cols[i]
+		c.Ordinal = i
+		c.Name = C.GoString(C.sqlite3_column_name(s.cStmt, C.int(i)))
+		c.DeclType = C.GoString(C.sqlite3_column_decltype(s.cStmt, C.int(i)))
+		c.DatabaseName = C.GoString(C.sqlite3_column_database_name(s.cStmt, C.int(i)))
+		c.TableName = C.GoString(C.sqlite3_column_table_name(s.cStmt, C.int(i)))
+		c.OriginName = C.GoString(C.sqlite3_column_origin_name(s.cStmt, C.int(i)))
+
+		if c.TableName == "" || c.OriginName == "" {
+			// an expression
+			continue
+		}
+
+		zDbName := C.CString(c.DatabaseName)
+		zTableName := C.CString(c.TableName)
+		zColumnName := C.CString(c.OriginName)
+
+		var pzDataType, pzCollSeq *C.char
+		var pNotNull, pPrimaryKey, pAutoinc C.int
+
+		rc := C.sqlite3_table_column_metadata(d.DBHwnd, zDbName, zTableName, zColumnName,
+			&pzDataType, &pzCollSeq, &pNotNull, &pPrimaryKey, &pAutoinc)
+
+		C.free(unsafe.Pointer(zDbName))
+		C.free(unsafe.Pointer(zTableName))
+		C.free(unsafe.Pointer(zColumnName))
+
+		if rc != SQLITE_OK {
+			continue
+		}
+
+		c.CollSeq = C.GoString(pzCollSeq)
+		c.NotNULL = pNotNull != 0
+		c.IsPrimaryKey = pPrimaryKey != 0
+		c.IsAutoIncrement = pAutoinc != 0
+	}
+
+	return cols
+}
+
+// stmtColumns describes the columns of a prepared statement for a
+// DataTable. DefaultValue and IsGeneratedAlways are taken from the
+// source table of a column (see GetTableColumns()); cached per table
+// by tableColumns().
+func (d *DB) stmtColumns(s *Stmt) []Column {
+
+	colTypes := d.stmtColumnTypes(s)
+	cols := make([]Column, len(colTypes))
+
+	tables := make(map[string][]Column)
+
+	for i, ct := range colTypes {
+		cols[i] = Column{
+			Name:            ct.Name,
+			DataType:        ct.DeclType,
+			IsPrimaryKey:    ct.IsPrimaryKey,
+			IsAutoIncrement: ct.IsAutoIncrement,
+			NotNULL:         ct.NotNULL,
+			Ordinal:         i,
+			DatabaseName:    ct.DatabaseName,
+			TableName:       ct.TableName,
+			OriginName:      ct.OriginName,
+		}
+
+		// GetTableColumns() describes the tables in main.
+		if ct.TableName == "" || ct.DatabaseName != "main" {
+			continue
+		}
+
+		tblCols, ok := tables[ct.TableName]
+		if !ok {
+			tblCols = d.tableColumns(ct.TableName)
+			tables[ct.TableName] = tblCols
+		}
+		for k := range tblCols {
+			if strings.EqualFold(tblCols[k].Name, ct.OriginName) {
+				cols[i].DefaultValue = tblCols[k].DefaultValue
+				cols[i].IsGeneratedAlways = tblCols[k].IsGeneratedAlways
+				break
+			}
+		}
+	}
+
+	return cols
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"strings"
+	"testing"
+)
+
+func openJoinTables(t *testing.T) *DB {
+	t.Helper()
+
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table person(id integer primary key autoincrement, name varchar(50) not null collate nocase)")
+	mustExec(t, db, "create table pet(id integer primary key, owner integer, kind text default 'cat')")
+	mustExec(t, db, "insert into person(name) values('a')")
+	mustExec(t, db, "insert into pet values(1, 1, 'dog')")
+
+	return db
+}
+
+const joinQuery = `select p.id, p.name as owner_name, t.kind, count(*) as n
+	from person p join pet t on t.owner = p.id group by p.id`
+
+func TestColumnTypes(t *testing.T) {
+	db := openJoinTables(t)
+
+	rows, err := db.Query(joinQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 4 {
+		t.Fatalf("got %d columns, want 4", len(cols))
+	}
+
+	id := cols[0]
+	if id.Name != "id" || id.DeclType != "INTEGER" || id.TableName != "person" || id.DatabaseName != "main" ||
+		!id.IsPrimaryKey || !id.IsAutoIncrement {
+		t.Fatalf("got %+v", id)
+	}
+
+	name := cols[1]
+	if name.Name != "owner_name" || name.OriginName != "name" || name.DeclType != "varchar(50)" ||
+		!name.NotNULL || !strings.EqualFold(name.CollSeq, "nocase") || name.IsPrimaryKey || name.Ordinal != 1 {
+		t.Fatalf("got %+v", name)
+	}
+
+	if kind := cols[2]; kind.TableName != "pet" || kind.OriginName != "kind" || kind.NotNULL {
+		t.Fatalf("got %+v", kind)
+	}
+
+	// an expression
+	if n := cols[3]; n.Name != "n" || n.DeclType != "" || n.TableName != "" || n.OriginName != "" {
+		t.Fatalf("got %+v", n)
+	}
+
+	rows.Close()
+	if _, err := rows.ColumnTypes(); err == nil {
+		t.Fatal("no error for closed rows")
+	}
+}
+
+func TestDataTableColumns(t *testing.T) {
+	db := openJoinTables(t)
+
+	dt, err := db.GetDataTable(joinQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt.Columns) != 4 {
+		t.Fatalf("got %d columns, want 4", len(dt.Columns))
+	}
+
+	if c := dt.Columns[1]; c.Name != "owner_name" || c.TableName != "person" || c.OriginName != "name" ||
+		c.DataType != "varchar(50)" || !c.NotNULL {
+		t.Fatalf("got %+v", c)
+	}
+	if c := dt.Columns[2]; c.TableName != "pet" || c.DefaultValue == nil {
+		t.Fatalf("got %+v", c)
+	}
+	if c := dt.Columns[3]; c.Name != "n" || c.TableName != "" || c.Ordinal != 3 {
+		t.Fatalf("got %+v", c)
+	}
+	if len(dt.Rows) != 1 || dt.Rows[0]["owner_name"] != "a" {
+		t.Fatalf("got %v", dt.Rows)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentDatabases runs writes and reads of two databases
+// from many goroutines; run it with -race.
+func TestConcurrentDatabases(t *testing.T) {
+	dbs := []*DB{openTestDB(t, true), openTestDB(t, false)}
+	for _, db := range dbs {
+		mustExec(t, db, "create table t(id integer primary key, g int)")
+	}
+
+	const goroutines, n = 8, 25
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		for _, db := range dbs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range n {
+					if _, err := db.Execute(fmt.Sprintf("insert into t(g) values(%d)", i)); err != nil {
+						t.Error(err)
+						return
+					}
+					dt, err := db.GetDataTable("select count(*) n from t where g = ?", i)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if got := dt.Rows[0]["n"]; got != int64(j+1) {
+						t.Errorf("got %v rows, want %d", got, j+1)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, db := range dbs {
+		if c := count(t, db, "select count(*) from t"); c != goroutines*n {
+			t.Fatalf("got %d rows, want %d", c, goroutines*n)
+		}
+	}
+}
+
+// TestIndependentDatabases checks that a database waiting for
+// a transaction does not hold up another database.
+func TestIndependentDatabases(t *testing.T) {
+	db1, db2 := openTestDB(t, false), openTestDB(t, false)
+	mustExec(t, db1, "create table t(a)")
+	mustExec(t, db2, "create table t(a)")
+
+	tx, err := db1.BeginTx(context.Background(), TxOptions{Mode: Immediate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// waits for the Tx
+	waiting := make(chan struct{})
+	go func() {
+		defer close(waiting)
+		db1.Exec("insert into t values(1)")
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		res := db2.Exec("insert into t values(1)")
+		done <- res.Error()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a write of a database waited for another database")
+	}
+
+	tx.Rollback()
+	<-waiting
+}
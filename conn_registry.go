@@ -0,0 +1,92 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include "sqlite3.h"
+import "C"
+import (
+	"strings"
+	"sync"
+)
+
+// connRegistry keeps what is registered on the connection of a DB
+// (e.g. functions; see RegisterFunc()), so that it is registered on
+// the readers of the pool as well. A reader that was opened before
+// the last registration is closed when it is released; see
+// connExpired().
+type connRegistry struct {
+	mu      sync.Mutex
+	gen     uint64
+	entries []connEntry
+}
+
+// connEntry is a registration; register is called once
+// per connection.
+type connEntry struct {
+	key      string
+	register func(hwnd *C.sqlite3) error
+}
+
+// add registers an entry on the writer connection (hwnd) and keeps it
+// for the readers; an entry with the same key is replaced. The caller
+// must hold the lock of the writer connection.
+func (r *connRegistry) add(hwnd *C.sqlite3, key string, register func(hwnd *C.sqlite3) error) error {
+	if err := register(hwnd); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key = strings.ToLower(key)
+	e := connEntry{key: key, register: register}
+
+	replaced := false
+	for i := range r.entries {
+		if r.entries[i].key == key {
+			r.entries[i] = e
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.entries = append(r.entries, e)
+	}
+	r.gen++
+
+	return nil
+}
+
+// apply registers the entries on a new connection; it returns
+// the generation of the registry.
+func (r *connRegistry) apply(hwnd *C.sqlite3) (uint64, error) {
+	if r == nil {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if err := e.register(hwnd); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.gen, nil
+}
+
+// generation changes with every registration.
+func (r *connRegistry) generation() uint64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.gen
+}
@@ -11,6 +11,7 @@ package gosqlite
 import "C"
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -107,15 +108,42 @@ func (d *DB) Close() error {
 		return errors.New("database is not open")
 	}
 
+	// the reader connections of the pool
+	d.closePool()
+
+	// wait for the statement in progress, if any
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	// sqlite3_close() fails while there are
+	// un-finalized statements.
+	d.stmtCache.clear()
+
 	res := C.sqlite3_close(d.DBHwnd)
 	err := getSQLiteErr(res, d.DBHwnd)
 
 	if err != nil {
-		if !strings.Contains(err.Error(), "bad parameter or other API misuse") {
+		if !errors.Is(err, ErrMisuse) {
 			return err
 		}
 	}
 
+	// the DBGroup drops the closed database on its next ping
+	d.Closed = true
+	d.DBHwnd = nil
+
+	if d.busy != nil {
+		d.busy.mu.Lock()
+		d.busy.releaseHandler()
+		d.busy.mu.Unlock()
+	}
+
+	// the channels of Subscribe() are closed
+	if d.hooks != nil {
+		d.hooks.close()
+	}
+
 	return nil
 }
 
@@ -195,7 +223,7 @@ func (d *DB) ReOpen() error {
 	DBGrp.Remove(d)
 	d, err = OpenV2(fp, jm)
 	if err != nil {
-		if strings.Contains(err.Error(), "database is locked") {
+		if errors.Is(err, ErrBusy) {
 			// try to delete the jounal file(s) and try one more time
 			DeleteJournalfiles(fp)
 			d, err = OpenV2(fp, jm)
@@ -540,15 +568,43 @@ func (d *DB) copyTableToDatabaseBulkInsert(newTableName string, tbleNameToCopyFr
 		col = append(col, allCols[i].Name)
 	}
 
-	insPart := strings.Join(col, `","`)
-	insPart = fmt.Sprintf(`("%s")`, insPart)
+	selCol := make([]string, len(col))
+	for i := range col {
+		selCol[i] = quoteIdent(col[i])
+	}
+	// the rows are read on the writer, without waiting for DB.mutex
+	// (as the Rows of Query() do, outside WAL mode); BulkInsert()
+	// holds it while it reads them.
+	rs, err := d.query(context.Background(), fmt.Sprintf("SELECT %s FROM main.%s", strings.Join(selCol, ", "), quoteIdent(tbleNameToCopyFrom)))
+	if err != nil {
+		d.DetachDB(attachName)
+		return err
+	}
 
-	insPartSelect := strings.TrimPrefix(insPart, "(")
-	insPartSelect = strings.TrimSuffix(insPartSelect, ")")
+	// stream the rows into the attached database
+	var errRead error
+	rows := func(yield func([]any) bool) {
+		for row, err := range rs.All() {
+			if err != nil {
+				errRead = err
+				return
+			}
+			v := make([]any, len(col))
+			for i := range v {
+				v[i] = RowView{rs: row}.Value(i)
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+
+	_, err = d.BulkInsert(context.Background(), newTableName, col, rows, BulkOptions{Schema: attachName})
+	rs.Close()
+	if err == nil {
+		err = errRead
+	}
 
-	sqlx := fmt.Sprintf("INSERT INTO %s.`%s`(%s) SELECT %s FROM main.`%s`;",
-		attachName, newTableName, insPartSelect, insPartSelect, tbleNameToCopyFrom)
-	_, err := d.Execute(sqlx)
 	errDetach := d.DetachDB(attachName)
 	if err != nil {
 		return err
@@ -1047,8 +1103,10 @@ func (d *DB) GetPage(pageSize int64, pageNo int64, tableName string, filter stri
 			}
 		}
 		if colFnd {
-			if sortOrder == "" {
-				sortOrder = "asc"
+			sortOrder, err = sortClause(sortOrder)
+			if err != nil {
+				dt.Err = err
+				return *dt, err
 			}
 			order = fmt.Sprintf("order by [%s] %s", orderBy, sortOrder)
 		}
@@ -1094,7 +1152,7 @@ func (d *DB) GetPage(pageSize int64, pageNo int64, tableName string, filter stri
 		// 	dt.Err = err
 		// }
 		var dtx DataTable
-		return dtx, err
+		return dtx, dt.Err
 	}
 
 	if len(dt.Columns) == 0 {
@@ -1106,6 +1164,28 @@ func (d *DB) GetPage(pageSize int64, pageNo int64, tableName string, filter stri
 	return *dt, nil
 }
 
+// sortClause checks the sortOrder of GetPage(); i.e. ASC or DESC,
+// optionally after a collation. e.g. "desc", "collate NOCASE" or
+// "collate UNICODE_NOCASE asc" (see RegisterCollation()).
+func sortClause(sortOrder string) (string, error) {
+	v := strings.Fields(sortOrder)
+
+	collate := ""
+	if len(v) >= 2 && strings.EqualFold(v[0], "collate") {
+		collate = "COLLATE " + quoteIdent(v[1]) + " "
+		v = v[2:]
+	}
+
+	switch {
+	case len(v) == 0:
+		return collate + "ASC", nil
+	case len(v) == 1 && (strings.EqualFold(v[0], "asc") || strings.EqualFold(v[0], "desc")):
+		return collate + strings.ToUpper(v[0]), nil
+	}
+
+	return "", fmt.Errorf("invalid sort order %q; [COLLATE name] ASC|DESC expected", sortOrder)
+}
+
 // GetPageOffset returns totalPages, offset, pageNo
 func (d *DB) GetPageOffset(recordCount int64, pageSize int64, pageNo int64) (int64, int64, int64) {
 
@@ -1215,18 +1295,16 @@ func (d *DB) GetPagingInfo(pageSize int64, pageNo int64, tableName string, filte
 	return ci, nil
 }
 
-// IsIdle reutrns true if a database is "busy" with
+// IsIdle reutrns false if a database is "busy" with
 // onr (or more( of the following operations
 // Exec(), GetDataTable(),  Execute(), ExececuteNonQuery(),
-// or ExececuteScalre()
+// or ExececuteScalre(); other databases are not counted.
 func (d *DB) IsIdle() bool {
+	if d.counters == nil {
+		return true
+	}
 
-	return ExecSeqNo < 1 &&
-		GetDataTableSeqNo < 1 &&
-		ExecuteSeqNo < 1 &&
-		ExececuteNonQuerySeqNo < 1 &&
-		ExececuteScalreSeqNo < 1 &&
-		QuerySeqNo < 1
+	return d.counters.inFlight.Load() < 1
 }
 
 func (d *DB) IsInMemory() bool {
@@ -1255,6 +1333,15 @@ func (d *DB) UpdateBlob(data []byte, tblName string, colName string, rowid int)
 	ph[1] = rowid
 
 	query := fmt.Sprintf("update %s set %s = ? where _rowid_ = ?", tblName, colName)
+
+	// other writes wait for the update; see doExec().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
 	s, _, err := d.Prepare(query, ph)
 	if err != nil {
 		res.rowsAffected = -1
@@ -1372,7 +1459,8 @@ func (d *DB) StmtReadOnly(sqlx string) bool {
 	pzTail := C.CString("")
 	defer C.free(unsafe.Pointer(pzTail))
 
-	defer C.sqlite3_finalize(ppStmt)
+	// ppStmt is set by sqlite3_prepare_v2()
+	defer func() { C.sqlite3_finalize(ppStmt) }()
 
 	rcx := C.sqlite3_prepare_v2(d.DBHwnd, zSql, C.int(nByte), &ppStmt, &pzTail)
 	if rcx != SQLITE_OK {
@@ -1453,6 +1541,7 @@ func (d *DB) TxRollback(txID string) error {
 	sqlx = fmt.Sprintf(`RELEASE "%s";`, txID)
 	sqlxx = C.CString(sqlx)
 	res = C.sqlite3_exec(d.DBHwnd, sqlxx, nil, nil, nil)
+	d.hooks.flush()
 
 	return getSQLiteErr(res, d.DBHwnd)
 }
@@ -1464,6 +1553,9 @@ func (d *DB) TxCommit(txID string) error {
 
 	res := C.sqlite3_exec(d.DBHwnd, sqlxx, nil, nil, nil)
 
+	// the subscriptions get the changes, if it committed
+	d.hooks.flush()
+
 	return getSQLiteErr(res, d.DBHwnd)
 }
 
@@ -8,47 +8,23 @@ package gosqlite
 // #include "sqlite3.h"
 import "C"
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var mResultQueue []queryResult
-var mCMutex sync.Mutex // global mutex
 var DBGrp IDBGroup = &DBGroup{}
 
-// GetResultSetSeqNo is incremented every time a request comes in
-// for GetDataTable. It keep track of the order of the request.
-// It is used to track requests; it is also returned to the caller.
-// This is sepcially helpful when the caller is using multiple threads
-// where results are returned out-of-order (not by the order in which
-// reques was received).
-var GetResultSetSeqNo uint
-
-// maxConcurrentRequest is a global counter, of all connections
-// (single and multi-threaded) for the DateDateTable(). It helps
-// keep track of freqeuent calls, and also helps in minimizing null-
-// pointer situations where releasing resources could go out-of-synce
-// between sqlite3 and that of Go. The caller can also use this counter
-// to keep track of its results when calls are made concurrently.
-// The following is an example of how a caller can create frequent/
-// multiple threads.
-//
-//	for i:=0; i < n; i++{
-//	  go db.GetDataTable("<some query with a fairly large result-set")
-//	}
-var GetDataTableSeqNo int
-var ExecSeqNo int
-var ExecuteSeqNo int
-var ExececuteNonQuerySeqNo int
-var ExececuteScalreSeqNo int
-var QuerySeqNo int
-
 type Value any
 
 type Stmt struct {
-	Query    string
+	SQL      string // the sql text of the statement
 	cStmt    *C.sqlite3_stmt
+	db       *DB
 	released bool
+	cached   bool // the statement belongs to the DB's statement cache
+	tx       *Tx  // the Tx that prepared the statement; see Tx.Prepare()
 }
 
 type Rows struct {
@@ -56,8 +32,14 @@ type Rows struct {
 	db       *DB
 	colCount int
 	columns  []string
-	intfc    IRows  // this makes sure IRows is implemented
-	NotUsed  string // for gobs - to have one exported field
+	err      error           // error from the last step, if any
+	closed   bool            // Next() reached the end or Close() was called
+	borrowed bool            // stmt belongs to a reusable Stmt; reset, not finalized
+	ctx      context.Context // aborts a step in Next(), when done
+	release  func()          // returns the connection to the pool, on Close()
+	txLock   sync.Locker     // DB.mutex of a query on the writer; each step waits for an open Tx
+	intfc    IRows           // this makes sure IRows is implemented
+	NotUsed  string          // for gobs - to have one exported field
 }
 type IRows interface {
 	Columns() ([]string, error)
@@ -80,8 +62,7 @@ const (
 	SCALARE    uint8 = 2
 )
 const (
-	reqverb_sqlite3          string = "sqlite3Request"
-	defaultPRAGMAJournalMode string = "PRAGMA main.journal_mode = TRUNCATE"
+	reqverb_sqlite3 string = "sqlite3Request"
 )
 
 // QueryResult is used by the Exec callbck to
@@ -112,35 +93,6 @@ type QueryResult struct {
 	TimeEnded time.Time
 }
 
-// queryResult is for internal processing
-// of requests in a queue.
-type queryResult struct {
-	SeqNo    uint
-	Result   []map[string]any
-	QueryID  string
-	Started  bool
-	Finished bool
-	Err      error
-
-	// ExecResult is the results returned by the C code.
-	// 0 means no error.
-	ExecResult int
-	Received   bool
-	Processed  bool
-
-	TimeStarted time.Time
-	TimeEnded   time.Time
-
-	// AttemptsAfterDBLocked is count of attemps to write.
-	// after a "database locked" error. If the database is
-	// locked, there will be three more attempts to try with
-	// the same query. If the database-lock is not cleared after the
-	// 3rd attemp, a database locked error will be
-	// returned to the caller.
-	AttemptsAfterDBLocked int
-	SQLStmt               string
-	QueryType             uint8
-}
 type DBStat struct {
 	// Size returns the database size in string format; i.e. 1,350KB, 2,535MB, 1.45GB
 	Size      string
@@ -166,6 +118,12 @@ type Column struct {
 	NotNULL           bool   `json:"NotNULL"`
 	Ordinal           int    `json:"Ordinal"`
 	DefaultValue      any    `json:"DefaultValue"`
+
+	// DatabaseName, TableName and OriginName (the name of the
+	// column in its table) are empty for an expression.
+	DatabaseName string `json:"DatabaseName"`
+	TableName    string `json:"TableName"`
+	OriginName   string `json:"OriginName"`
 }
 type AttachedDB struct {
 	Name       string `json:"Name"`
@@ -284,6 +242,10 @@ type Connection struct {
 	TimeConnected time.Time
 	ID            string
 	SeqNo         uint
+
+	// ReadOnly is true for the reader connections of the
+	// pool; false for the writer connection (DBHwnd).
+	ReadOnly bool
 }
 
 type DB struct {
@@ -327,8 +289,7 @@ type DB struct {
 
 	Post IPost
 
-	getResultSetConnections uint
-	intfce                  IDB // this makes sure IDB is implemented
+	intfce IDB // this makes sure IDB is implemented
 
 	filePath      string // full path of the database file.
 	daemonStarted bool
@@ -339,8 +300,43 @@ type DB struct {
 	eQueueRead  *exeQueue
 	eQueueQuery *exeQueue
 	mutex       sync.Mutex
-	tStmtQBusy  bool
 	tStmtQ      []sqlStmt
+
+	// inTx is whether a Tx (see BeginTx()) holds mutex;
+	// the reads then run on the readers. See readConn().
+	inTx atomic.Bool
+
+	// stmtCache keeps compiled statements by their sql text.
+	stmtCache *stmtCache
+
+	// busy is the retry policy and the busy handler;
+	// see SetRetryPolicy() and SetBusyHandler().
+	busy *busyState
+
+	// pool is the reader connections; see pool.go.
+	pool *connPool
+
+	// connMu is the lock of the connection (DBHwnd); the writer
+	// and every reader of the pool has its own. See connLock().
+	connMu *connMutex
+
+	// counters are shared with the readers of the pool;
+	// see IsIdle() and nextSeqNo().
+	counters *dbCounters
+
+	vfsName string // the vfs the database was opened with
+
+	// connPragmas are applied to every reader of the
+	// pool, when it is opened; see OpenWith().
+	connPragmas []string
+
+	// registry is what is registered on the connection (e.g.
+	// functions); shared with the readers of the pool.
+	registry *connRegistry
+
+	// hooks are the update, commit and rollback hooks of the
+	// connection, and the subscriptions; see Subscribe().
+	hooks *hookState
 }
 
 type sqlStmt struct {
@@ -0,0 +1,152 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"errors"
+)
+
+// extended result codes; see: https://www.sqlite.org/rescode.html#extrc
+const (
+	SQLITE_BUSY_RECOVERY         = SQLITE_BUSY | (1 << 8)
+	SQLITE_BUSY_SNAPSHOT         = SQLITE_BUSY | (2 << 8)
+	SQLITE_BUSY_TIMEOUT          = SQLITE_BUSY | (3 << 8)
+	SQLITE_LOCKED_SHAREDCACHE    = SQLITE_LOCKED | (1 << 8)
+	SQLITE_READONLY_RECOVERY     = SQLITE_READONLY | (1 << 8)
+	SQLITE_READONLY_CANTLOCK     = SQLITE_READONLY | (2 << 8)
+	SQLITE_READONLY_ROLLBACK     = SQLITE_READONLY | (3 << 8)
+	SQLITE_READONLY_DBMOVED      = SQLITE_READONLY | (4 << 8)
+	SQLITE_CORRUPT_VTAB          = SQLITE_CORRUPT | (1 << 8)
+	SQLITE_CONSTRAINT_CHECK      = SQLITE_CONSTRAINT | (1 << 8)
+	SQLITE_CONSTRAINT_COMMITHOOK = SQLITE_CONSTRAINT | (2 << 8)
+	SQLITE_CONSTRAINT_FOREIGNKEY = SQLITE_CONSTRAINT | (3 << 8)
+	SQLITE_CONSTRAINT_FUNCTION   = SQLITE_CONSTRAINT | (4 << 8)
+	SQLITE_CONSTRAINT_NOTNULL    = SQLITE_CONSTRAINT | (5 << 8)
+	SQLITE_CONSTRAINT_PRIMARYKEY = SQLITE_CONSTRAINT | (6 << 8)
+	SQLITE_CONSTRAINT_TRIGGER    = SQLITE_CONSTRAINT | (7 << 8)
+	SQLITE_CONSTRAINT_UNIQUE     = SQLITE_CONSTRAINT | (8 << 8)
+	SQLITE_CONSTRAINT_VTAB       = SQLITE_CONSTRAINT | (9 << 8)
+	SQLITE_CONSTRAINT_ROWID      = SQLITE_CONSTRAINT | (10 << 8)
+	SQLITE_CONSTRAINT_PINNED     = SQLITE_CONSTRAINT | (11 << 8)
+	SQLITE_CONSTRAINT_DATATYPE   = SQLITE_CONSTRAINT | (12 << 8)
+	SQLITE_IOERR_SHORT_READ      = SQLITE_IOERR | (2 << 8)
+	SQLITE_CANTOPEN_NOTEMPDIR    = SQLITE_CANTOPEN | (1 << 8)
+	SQLITE_ABORT_ROLLBACK        = SQLITE_ABORT | (2 << 8)
+)
+
+// Error is an error returned by sqlite3. It can be matched with
+// errors.Is() against the sentinels below (e.g. ErrBusy), or
+// unwrapped with errors.As() for its codes, e.g.
+//
+//	var e *gosqlite.Error
+//	if errors.As(err, &e) {
+//		fmt.Println(e.Code, e.ExtendedCode, e.Offset)
+//	}
+//
+// See: https://www.sqlite.org/rescode.html
+type Error struct {
+	// Code is the primary result code; e.g. SQLITE_CONSTRAINT.
+	Code int
+
+	// ExtendedCode is the extended result code;
+	// e.g. SQLITE_CONSTRAINT_UNIQUE.
+	ExtendedCode int
+
+	// Message is the english-language text of the
+	// error (sqlite3_errmsg()).
+	Message string
+
+	// SQL is the sql statement that failed, if any.
+	SQL string
+
+	// Offset is the byte offset of the token in SQL that
+	// caused the error (sqlite3_error_offset()); -1 if
+	// not applicable.
+	Offset int
+}
+
+// sentinels to be used with errors.Is(); an Error matches a
+// sentinel by its ExtendedCode (if set in the sentinel) or else
+// by its Code.
+var (
+	ErrBusy                 = &Error{Code: SQLITE_BUSY, Message: "database is locked", Offset: -1}
+	ErrLocked               = &Error{Code: SQLITE_LOCKED, Message: "database table is locked", Offset: -1}
+	ErrReadOnly             = &Error{Code: SQLITE_READONLY, Message: "attempt to write a readonly database", Offset: -1}
+	ErrInterrupt            = &Error{Code: SQLITE_INTERRUPT, Message: "interrupted", Offset: -1}
+	ErrCorrupt              = &Error{Code: SQLITE_CORRUPT, Message: "database disk image is malformed", Offset: -1}
+	ErrNoMem                = &Error{Code: SQLITE_NOMEM, Message: "out of memory", Offset: -1}
+	ErrFull                 = &Error{Code: SQLITE_FULL, Message: "database or disk is full", Offset: -1}
+	ErrCantOpen             = &Error{Code: SQLITE_CANTOPEN, Message: "unable to open database file", Offset: -1}
+	ErrNotADB               = &Error{Code: SQLITE_NOTADB, Message: "file is not a database", Offset: -1}
+	ErrMisuse               = &Error{Code: SQLITE_MISUSE, Message: "bad parameter or other API misuse", Offset: -1}
+	ErrConstraint           = &Error{Code: SQLITE_CONSTRAINT, Message: "constraint failed", Offset: -1}
+	ErrConstraintUnique     = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_UNIQUE, Message: "UNIQUE constraint failed", Offset: -1}
+	ErrConstraintPrimaryKey = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_PRIMARYKEY, Message: "PRIMARY KEY constraint failed", Offset: -1}
+	ErrConstraintForeignKey = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_FOREIGNKEY, Message: "FOREIGN KEY constraint failed", Offset: -1}
+	ErrConstraintNotNull    = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_NOTNULL, Message: "NOT NULL constraint failed", Offset: -1}
+	ErrConstraintCheck      = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_CHECK, Message: "CHECK constraint failed", Offset: -1}
+)
+
+// Error returns the message of sqlite3; i.e. the same
+// text as sqlite3_errmsg().
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether e matches a sentinel error (see ErrBusy, etc.).
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t == nil {
+		return false
+	}
+
+	if t.ExtendedCode != 0 {
+		return e.ExtendedCode == t.ExtendedCode
+	}
+
+	return e.Code == t.Code
+}
+
+// newError creates an Error from a result code and the error
+// state of the connection.
+func newError(res C.int, dbHwnd *C.sqlite3) *Error {
+	e := &Error{
+		Code:         int(res) & 0xff,
+		ExtendedCode: int(res),
+		Offset:       -1,
+	}
+
+	if dbHwnd == nil {
+		e.Message = C.GoString(C.sqlite3_errstr(res))
+		return e
+	}
+
+	// the extended code of the connection is more specific,
+	// if it refers to the same (primary) error.
+	ext := int(C.sqlite3_extended_errcode(dbHwnd))
+	if ext&0xff == e.Code {
+		e.ExtendedCode = ext
+		e.Message = C.GoString(C.sqlite3_errmsg(dbHwnd))
+		e.Offset = int(C.sqlite3_error_offset(dbHwnd))
+	} else {
+		e.Message = C.GoString(C.sqlite3_errstr(res))
+	}
+
+	return e
+}
+
+// withSQL sets the sql statement of an Error.
+func withSQL(err error, sqlx string) error {
+	var e *Error
+	if errors.As(err, &e) && e.SQL == "" {
+		e.SQL = sqlx
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorConstraint(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a unique, b not null)")
+	mustExec(t, db, "insert into t values(1, 1)")
+
+	const insert = "insert into t values(1, 2)"
+	res := db.Exec(insert)
+	err := res.Error()
+	if !errors.Is(err, ErrConstraintUnique) || !errors.Is(err, ErrConstraint) {
+		t.Fatalf("got %v, want ErrConstraintUnique", err)
+	}
+	if errors.Is(err, ErrConstraintNotNull) || errors.Is(err, ErrBusy) {
+		t.Fatalf("%v matches another sentinel", err)
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %T, want *Error", err)
+	}
+	if e.Code != SQLITE_CONSTRAINT || e.ExtendedCode != SQLITE_CONSTRAINT_UNIQUE || e.SQL != insert {
+		t.Fatalf("got %+v", e)
+	}
+
+	_, err = db.ExecuteNonQuery("insert into t values(2, null)")
+	if !errors.Is(err, ErrConstraintNotNull) {
+		t.Fatalf("got %v, want ErrConstraintNotNull", err)
+	}
+}
+
+func TestErrorOffset(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	const query = "select a, nosuchcolumn from t"
+	_, err := db.GetDataTable(query)
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want *Error", err)
+	}
+	if e.Code != SQLITE_ERROR || e.Offset != 10 || e.SQL != query {
+		t.Fatalf("got %+v", e)
+	}
+}
+
+func TestErrorReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := OpenWith(path, WithCreate(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db, "create table t(a)")
+	db.Close()
+
+	db, err = OpenWith(path, WithReadOnly(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	res := db.Exec("insert into t values(1)")
+	if err := res.Error(); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("got %v, want ErrReadOnly", err)
+	}
+}
+
+func TestErrorNotADB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := os.WriteFile(path, []byte("this is not a database file; it is just text in a file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenWith(path, WithoutGroupTracking())
+	if err == nil {
+		defer db.Close()
+		_, err = db.GetDataTable("select * from sqlite_master")
+	}
+	if !errors.Is(err, ErrNotADB) {
+		t.Fatalf("got %v, want ErrNotADB", err)
+	}
+}
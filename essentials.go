@@ -8,13 +8,23 @@ package gosqlite
 //#include <stdio.h>
 //#include <stdlib.h>
 //#include "sqlite3.h"
+// static int bind_text_transient(sqlite3_stmt *s, int i, const char *z, int n){
+//   return sqlite3_bind_text(s, i, z, n, SQLITE_TRANSIENT);
+// }
+// static int bind_blob_transient(sqlite3_stmt *s, int i, const void *z, int n){
+//   return sqlite3_bind_blob(s, i, z, n, SQLITE_TRANSIENT);
+// }
 import "C"
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 	"unsafe"
@@ -34,7 +44,7 @@ func (rs *Rows) DescribeColumns(tableName string) []Column {
 
 func (rs *Rows) Columns() ([]string, error) {
 
-	if rs == nil || rs.stmt == nil || rs.stmt.cStmt == nil || rs.stmt.released {
+	if rs == nil || rs.stmt == nil || rs.stmt.cStmt == nil || rs.stmt.released || rs.closed {
 		return []string{}, errors.New("row is already closed")
 	}
 
@@ -50,76 +60,58 @@ func (rs *Rows) Columns() ([]string, error) {
 
 func (rs *Rows) Close() error {
 
-	if rs == nil || rs.stmt.released {
+	if rs == nil {
+		return nil
+	}
+
+	if rs.release != nil {
+		// after the statement is released
+		defer rs.release()
+		rs.release = nil
+	}
+
+	if rs.stmt == nil || rs.stmt.released || rs.closed {
+		return nil
+	}
+
+	rs.closed = true
+
+	if rs.borrowed {
+		// the statement is re-used by its Stmt
+		C.sqlite3_reset(rs.stmt.cStmt)
+		return nil
+	}
+
+	if rs.stmt.cached {
+		rs.db.releaseStmt(rs.stmt)
 		return nil
 	}
 
 	rc := C.sqlite3_finalize(rs.stmt.cStmt)
+	rs.stmt.released = true
 
 	if rc != SQLITE_OK {
 		return getSQLiteErr(rc, rs.db.DBHwnd)
 	}
 
-	rs.stmt.released = true
-
 	return nil
 }
 
 func (d *DB) exec(sqlx string, p ...any) (res Result) {
-	c := make(chan Result)
-	go func() {
-		var resw Result
-		sqlx = normalizeSQL(sqlx)
-		v := strings.Split(sqlx, ";")
-		for i := range v {
-			query := strings.TrimSpace(v[i])
-			if len(query) == 0 {
-				continue
-			}
-			sqlxx := v[i]
-			s, _, err := d.Prepare(sqlxx, p)
-			if err == nil {
-				rc := C.sqlite3_step(s.cStmt)
-				if rc != SQLITE_DONE {
-					err = getSQLiteErr(rc, d.DBHwnd)
-					resw.rowsAffected = -1
-					resw.err = err
-					resw.intfce = &resw
-				} else {
-					resw.rowsAffected = int64(C.sqlite3_changes(d.DBHwnd))
-					resw.lastInsertId = int64(C.sqlite3_last_insert_rowid(d.DBHwnd))
-				}
-				C.sqlite3_finalize(s.cStmt)
-			} else {
-				resw.rowsAffected = -1
-				resw.err = err
-				resw.intfce = &resw
-			}
-		}
-
-		c <- resw
-	}()
-	res = <-c
-	close(c)
-	return
+	// the statements are split by sqlite3; see execScript().
+	return d.execDo(context.Background(), sqlx, p...)
 }
 
+// ExecWithContext executes a query; the running statement is
+// aborted when ctx is done, and the error is ctx.Err() (i.e.
+// context.Canceled or context.DeadlineExceeded). Other statements
+// on the connection are not affected.
 func (d *DB) ExecWithContext(ctx context.Context, query string, placeHolders ...any) Result {
-	var wrkRes Result
-	c := make(chan Result)
-	go func(ctx context.Context) {
-		select {
-		case c <- d.Exec(query, placeHolders...):
-		case <-ctx.Done():
-			// should not get here, if func succeeds
-			wrkRes.err = ctx.Err()
-			c <- wrkRes
-		}
-	}(ctx)
-	wrkRes = <-c
-	close(c)
+	if err := ctx.Err(); err != nil {
+		return Result{rowsAffected: -1, err: err}
+	}
 
-	return wrkRes
+	return d.doExec(ctx, query, placeHolders...)
 }
 
 /*
@@ -240,39 +232,24 @@ func (d *DB) TruncateTable(tblName string) (err error) {
 
 func (d *DB) doQ() {
 
-	if d.tStmtQBusy {
-		return
-	}
-
-	d.tStmtQBusy = true
-	tryCnt := 0
-	timeout := 1000000
+	// one at a time; other writes wait.
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 
-	for {
-		if len(d.tStmtQ) == 0 {
-			break
-		}
+	for len(d.tStmtQ) > 0 {
 		item := d.tStmtQ[0]
-		res := d.execDo(item.SQLText, item.Args...)
+		// a locked database is retried by execDo()
+		// per the retry policy.
+		res := d.execDo(context.Background(), item.SQLText, item.Args...)
 		if res.Error() != nil {
-			if res.Error().Error() != "database is locked" || tryCnt > timeout {
-				break
-			}
-			//C.sqlite3_sleep(50)
-			tryCnt++
-			continue
+			break
 		}
 
-		if len(d.tStmtQ) > 0 {
-			d.tStmtQ = d.tStmtQ[1:]
-		}
-		//C.sqlite3_sleep(100)
+		d.tStmtQ = d.tStmtQ[1:]
 	}
-
-	d.tStmtQBusy = false
 }
 
-func (d *DB) doExec(query string, args ...any) Result {
+func (d *DB) doExec(ctx context.Context, query string, args ...any) Result {
 	var res Result
 
 	if d == nil {
@@ -280,22 +257,20 @@ func (d *DB) doExec(query string, args ...any) Result {
 		return res
 	}
 
+	// see ConnPool.MaxConcurrentRequests
+	leave, err := d.enterRequest(ctx)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer leave()
+
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	timeout := 1000000
-
-	for range timeout {
-		res = d.execDo(query, args...)
-		if res.Error() != nil {
-			if res.Error().Error() != "database is locked" {
-				break
-			}
-			continue
-		} else {
-			break
-		}
-	}
+	// a locked database is retried by execDo()
+	// per the retry policy.
+	res = d.execDo(ctx, query, args...)
 
 	return res
 }
@@ -315,18 +290,15 @@ func (d *DB) ExecNoWait(query string, placeHolders ...any) {
 }
 
 func (d *DB) Exec(query string, placeHolders ...any) Result {
-	return d.doExec(query, placeHolders...)
+	return d.doExec(context.Background(), query, placeHolders...)
 }
 
-func (d *DB) execDo(query string, placeHolders ...any) Result {
+func (d *DB) execDo(ctx context.Context, query string, placeHolders ...any) Result {
 
 	var wrk Result
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
-
-	wrk.rowsAffected = -1
 
 	if d == nil || d.Closed {
+		wrk.rowsAffected = -1
 		wrk.err = errors.New("database is not open")
 		return wrk
 	}
@@ -334,54 +306,24 @@ func (d *DB) execDo(query string, placeHolders ...any) Result {
 	if !d.isInMemory {
 		_, err := os.Stat(d.filePath)
 		if os.IsNotExist(err) {
+			wrk.rowsAffected = -1
 			wrk.err = errors.New("database does not exist")
 			return wrk
 		}
 	}
 
-	ExecSeqNo++
-
-	c := make(chan Result)
-	go func() {
-		var resw Result
-
-		// remove comments here
-		query = normalizeSQL(query)
-
-		v := strings.Split(query, ";")
-		for i := range v {
-			newQuery := strings.TrimSpace(v[i])
-			if len(newQuery) == 0 {
-				continue
-			}
-			s, _, err := d.Prepare(newQuery, placeHolders)
-			if err == nil && s.cStmt != nil {
-				rc := C.sqlite3_step(s.cStmt)
-				if rc != SQLITE_DONE {
-					err = getSQLiteErr(rc, d.DBHwnd)
-					resw.rowsAffected = -1
-					resw.err = err
-					resw.intfce = &resw
-				} else {
-					resw.rowsAffected = int64(C.sqlite3_changes(d.DBHwnd))
-					resw.lastInsertId = int64(C.sqlite3_last_insert_rowid(d.DBHwnd))
-				}
-
-				C.sqlite3_finalize(s.cStmt)
-
-			} else {
-				resw.rowsAffected = -1
-				resw.err = err
-				resw.intfce = &resw
-			}
-		}
-
-		c <- resw
-	}()
-	wrk = <-c
-	close(c)
-
-	ExecSeqNo--
+	// the statements are split by sqlite3 (not by ";"), so a
+	// semicolon in a string literal is not a problem; the result
+	// is of the last statement. See execScript().
+	res, err := d.execScript(ctx, query, placeHolders)
+	if len(res) > 0 {
+		wrk = res[len(res)-1]
+	}
+	if err != nil && wrk.err == nil {
+		// e.g. args left over
+		wrk.rowsAffected = -1
+		wrk.err = err
+	}
 
 	return wrk
 }
@@ -398,31 +340,26 @@ func (r *Result) LastInsertId() (int64, error) {
 	return r.lastInsertId, r.err
 }
 
+// ScanWithContext scans the current row, unless ctx is done.
+// The statement runs in Next(); to abort it when ctx is done,
+// use QueryWithContext().
 func (rs *Rows) ScanWithContext(ctx context.Context, args ...any) error {
-	var wrkRes error
-	c := make(chan error)
-	go func(ctx context.Context) {
-		select {
-		case c <- rs.scanInside(args...):
-		case <-ctx.Done():
-			// should not get here, if func succeeds
-			c <- ctx.Err()
-		}
-	}(ctx)
-	wrkRes = <-c
-	close(c)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	return wrkRes
+	return rs.scanInside(args...)
 }
 
 func (rs *Rows) scanInside(arg ...any) error {
 
-	if rs == nil || rs.stmt == nil || rs.stmt.released {
+	if rs == nil || rs.stmt == nil || rs.stmt.released || rs.closed {
 		return errors.New("row is already closed")
 	}
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	mu := rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	argLen := len(arg)
 	var cVal *C.char
@@ -443,128 +380,213 @@ func (rs *Rows) scanInside(arg ...any) error {
 		}
 	}
 
-	ptrSymbol := '*' // for easier parsing
-
 	for i := range argLen {
 		val := rs.db.getStmtColVal(rs.stmt, i)
 		if val == nil {
+			if sc, ok := arg[i].(sql.Scanner); ok {
+				// e.g. sql.NullString
+				if err := sc.Scan(nil); err != nil {
+					return err
+				}
+				continue
+			}
 			arg[i] = nil // override any pre-init of the arg
 
 			// must continue or panic
 			continue
 		}
 
-		t := reflect.TypeOf(val).Kind().String()
-		ptrType := reflect.TypeOf(arg[i])
+		if err := assignColVal(val, arg[i], i); err != nil {
+			return err
+		}
+	}
 
-		// let the caller know, in case it's a double+ pointer
-		ptrSymbolCount := strings.Count(ptrType.String(), string(ptrSymbol))
-		if ptrSymbolCount > 1 {
-			ptrClean := strings.ReplaceAll(ptrType.String(), string(ptrSymbol), "")
-			return fmt.Errorf("expected *%s not %s in arg %d", ptrClean, ptrType.String(), i)
+	dirty := false
+	for i := range arg {
+		if arg[i] != nil {
+			dirty = true
+			break
 		}
+	}
 
-		// Only TEXT, INTEGER, REAL and BLOB (see: https://www.sqlitetutorial.net/sqlite-data-types).
-		// However, time.Time and bool conversions are added for convenience (time/date type is TEXT, and
-		// bool is INTEGER in sqlite).
+	if !dirty {
+		return errors.New("scan had no results")
+	}
 
-		switch t {
-		case "int64":
-			// INTEGER
-			switch ptrType.String() {
-			case "*[]uint8":
-				// the column type is BLOB
-				// the value has been entered as INTEGER
-				arg[i] = val
+	return nil
+}
 
-			case "*int":
-				s := new(int)
-				s = arg[i].(*int)
-				*s = int(val.(int64))
-				arg[i] = s
+// assignColVal converts a column value (other than NULL) to the
+// type of dest, which is a pointer; i is the index of the column.
+// A dest that implements sql.Scanner scans the value itself.
+func assignColVal(val any, dest any, i int) error {
+
+	if sc, ok := dest.(sql.Scanner); ok {
+		if _, isNullTime := dest.(*sql.NullTime); isNullTime {
+			// there is no date/time type in sqlite3; only text
+			if str, isText := val.(string); isText {
+				tm, err := ConvertStringToTime(str)
+				if err != nil {
+					return fmt.Errorf("could not convert %q to time.Time in arg %d", str, i)
+				}
+				val = tm
+			}
+		}
+		return sc.Scan(val)
+	}
 
-			case "*bool":
-				// INTEGER
-				s := new(bool)
-				s = arg[i].(*bool)
-				*s = val.(int64) > 0
-				arg[i] = s
+	ptrType := reflect.TypeOf(dest)
+	if ptrType.Kind() != reflect.Pointer {
+		return fmt.Errorf("arg %d is not a reference to a pointer", i)
+	}
 
-			case "*int64":
-				s := new(int64)
-				s = arg[i].(*int64)
-				*s = val.(int64)
-				arg[i] = s
+	// let the caller know, in case it's a double+ pointer
+	if ptrType.Elem().Kind() == reflect.Pointer {
+		ptrClean := strings.ReplaceAll(ptrType.String(), "*", "")
+		return fmt.Errorf("expected *%s not %s in arg %d", ptrClean, ptrType.String(), i)
+	}
+
+	dv := reflect.ValueOf(dest).Elem()
+
+	// Only TEXT, INTEGER, REAL and BLOB (see: https://www.sqlitetutorial.net/sqlite-data-types).
+	// However, time.Time and bool conversions are added for convenience (time/date type is TEXT, and
+	// bool is INTEGER in sqlite).
+
+	if dv.Type() == reflect.TypeOf(time.Time{}) {
+		str, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("cannot convert %s to %s in arg %d", GetSQLiteDataType(val), ptrType, i)
+		}
+		// try to conver to time
+		tm, _ := ConvertStringToTime(str)
+		dv.Set(reflect.ValueOf(tm))
+		return nil
+	}
+
+	switch v := val.(type) {
+	case int64:
+		// INTEGER
+		switch dv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if dv.OverflowInt(v) {
+				return fmt.Errorf("value %d overflows %s in arg %d", v, ptrType, i)
 			}
+			dv.SetInt(v)
 
-		case "float64":
-			switch ptrType.String() {
-			case "*float64":
-				// REAL
-				s := new(float64)
-				s = arg[i].(*float64)
-				*s = val.(float64)
-				arg[i] = s
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v < 0 || dv.OverflowUint(uint64(v)) {
+				return fmt.Errorf("value %d overflows %s in arg %d", v, ptrType, i)
+			}
+			dv.SetUint(uint64(v))
 
-			case "*[]uint8":
-				// the column type is BLOB;
-				// the value has been entered as REAL
-				arg[i] = val
+		case reflect.Float32, reflect.Float64:
+			dv.SetFloat(float64(v))
+
+		case reflect.Bool:
+			dv.SetBool(v > 0)
+
+		case reflect.String:
+			dv.SetString(strconv.FormatInt(v, 10))
+
+		default:
+			if !isBytes(dv) {
+				return fmt.Errorf("cannot convert INTEGER to %s in arg %d", ptrType, i)
 			}
+			// the column type is BLOB
+			// the value has been entered as INTEGER
+			dv.SetBytes([]byte(strconv.FormatInt(v, 10)))
+		}
 
-		case "string":
-			// TEXT
-			switch ptrType.String() {
-			case "*string":
-				s := new(string)
-				s = arg[i].(*string)
-				*s = val.(string)
-				arg[i] = s
+	case float64:
+		// REAL
+		switch dv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if dv.OverflowFloat(v) {
+				return fmt.Errorf("value %g overflows %s in arg %d", v, ptrType, i)
+			}
+			dv.SetFloat(v)
 
-			case "*time.Time":
-				s := new(time.Time)
-				s = arg[i].(*time.Time)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v != math.Trunc(v) {
+				return fmt.Errorf("cannot convert %g to %s in arg %d", v, ptrType, i)
+			}
+			return assignColVal(int64(v), dest, i)
 
-				// try to conver to time
-				*s, _ = ConvertStringToTime(val.(string))
-				arg[i] = s
+		case reflect.String:
+			dv.SetString(strconv.FormatFloat(v, 'g', -1, 64))
 
-			case "*[]uint8":
-				// the column type is BLOB;
-				// the value has been entered as TEXT
-				arg[i] = val
+		default:
+			if !isBytes(dv) {
+				return fmt.Errorf("cannot convert REAL to %s in arg %d", ptrType, i)
 			}
+			// the column type is BLOB;
+			// the value has been entered as REAL
+			dv.SetBytes([]byte(strconv.FormatFloat(v, 'g', -1, 64)))
+		}
 
-		case "slice":
-			// BLOB
-			if ptrType.String() != "*[]uint8" {
-				return fmt.Errorf("expected *[]uint8 (*[]byte) not %s in arg %d", ptrType.String(), i)
+	case string:
+		// TEXT
+		switch dv.Kind() {
+		case reflect.String:
+			dv.SetString(v)
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s in arg %d", v, ptrType, i)
 			}
-			s := new([]byte)
-			s = arg[i].(*[]byte)
-			*s = val.([]byte)
-			arg[i] = s
+			return assignColVal(n, dest, i)
+
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s in arg %d", v, ptrType, i)
+			}
+			return assignColVal(f, dest, i)
+
+		case reflect.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s in arg %d", v, ptrType, i)
+			}
+			dv.SetBool(b)
 
 		default:
-			return fmt.Errorf("could not recognize pointer of type *%s in arg %d", ptrType.String(), i)
+			if !isBytes(dv) {
+				return fmt.Errorf("cannot convert TEXT to %s in arg %d", ptrType, i)
+			}
+			// the column type is BLOB;
+			// the value has been entered as TEXT
+			dv.SetBytes([]byte(v))
 		}
-	}
 
-	dirty := false
-	for i := range arg {
-		if arg[i] != nil {
-			dirty = true
-			break
+	case []byte:
+		// BLOB
+		switch {
+		case isBytes(dv):
+			dv.SetBytes(v)
+
+		case dv.Kind() == reflect.String:
+			dv.SetString(string(v))
+
+		default:
+			return fmt.Errorf("expected *[]uint8 (*[]byte) not %s in arg %d", ptrType, i)
 		}
-	}
 
-	if !dirty {
-		return errors.New("scan had no results")
+	default:
+		return fmt.Errorf("could not recognize pointer of type %s in arg %d", ptrType, i)
 	}
 
 	return nil
 }
 
+// isBytes reports whether v is a []byte (or a type of it).
+func isBytes(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
+}
+
 // Scan scans rows without a context; the calller
 // will get a busy or locked error, if the target
 // database is being written to.
@@ -724,17 +746,11 @@ func (r *Result) RowsAffected() (int64, error) {
 func (d *DB) prepareFixPlaceholders(placeHolders []any) []any {
 	var newPL []any
 	for i := range placeHolders {
-		if placeHolders[i] == nil {
-			continue
-		}
-		if reflect.TypeOf(placeHolders[i]).Kind() == reflect.Slice {
-			if reflect.TypeOf(placeHolders[i]).String() == "[]uint8" {
-				one := placeHolders[i].([]byte)
-				newPL = append(newPL, one)
-			} else {
-				newPL = append(newPL, placeHolders[i].([]any)...)
-			}
+		if xa, ok := placeHolders[i].([]any); ok {
+			newPL = append(newPL, xa...)
 		} else {
+			// incl. nil (keeps the position; it binds as NULL)
+			// and []byte (BLOB)
 			newPL = append(newPL, placeHolders[i])
 		}
 	}
@@ -759,12 +775,11 @@ func (d *DB) Prepare(
 	error) {
 
 	var s Stmt
-	s.Query = sqlx
+	s.SQL = sqlx
+	s.db = d
 	var ppStmt *C.sqlite3_stmt /* Statement handle */
 	var zSql *C.char
 
-	placeHolders = d.prepareFixPlaceholders(placeHolders)
-
 	nByte := len(sqlx)
 	zSql = C.CString(sqlx)
 	defer C.free(unsafe.Pointer(zSql))
@@ -775,12 +790,40 @@ func (d *DB) Prepare(
 	rc := C.sqlite3_prepare_v2(d.DBHwnd, zSql, C.int(nByte), &ppStmt, &pzTail)
 	if rc != SQLITE_OK {
 		C.sqlite3_finalize(ppStmt)
-		return s, strings.TrimSpace(C.GoString(pzTail)), getSQLiteErr(rc, d.DBHwnd)
+		return s, strings.TrimSpace(C.GoString(pzTail)), withSQL(getSQLiteErr(rc, d.DBHwnd), sqlx)
 	}
 
 	s.cStmt = ppStmt
-	var pChr *C.char
-	defer C.free(unsafe.Pointer(pChr))
+
+	if err := s.bind(placeHolders); err != nil {
+		C.sqlite3_finalize(ppStmt)
+		s.cStmt = nil
+		return s, strings.TrimSpace(C.GoString(pzTail)), err
+	}
+
+	return s, strings.TrimSpace(C.GoString(pzTail)), nil
+}
+
+// bind binds values to the place holders of a prepared statement.
+// See: https://www.sqlite.org/lang_expr.html#varparam,
+// and https://www.sqlite.org/c3ref/bind_blob.html
+func (s *Stmt) bind(placeHolders []any) error {
+
+	var rc C.int = SQLITE_OK
+	ppStmt := s.cStmt
+
+	placeHolders = s.db.prepareFixPlaceholders(placeHolders)
+
+	// a map, a struct or sql.Named() values; see namedValues()
+	m, strict, isNamed, err := namedValues(placeHolders)
+	if err != nil {
+		return err
+	}
+	if isNamed {
+		if placeHolders, err = s.bindNamed(m, strict); err != nil {
+			return err
+		}
+	}
 
 	paramCnt := int(C.sqlite3_bind_parameter_count(ppStmt))
 
@@ -789,21 +832,16 @@ func (d *DB) Prepare(
 	for i := range placeHolders {
 		exitLoop := false
 		if placeHolders[i] != nil {
-			if reflect.TypeOf(placeHolders[i]).Kind() == reflect.Slice {
-				isBlob := reflect.TypeOf(placeHolders[i]).String() == "[]uint8"
-				// If isBlob is true, it's a blob;caller has included []bytes in the argument list;
-				// so, a nil check is not necessary.
-				if !isBlob {
-					xa := placeHolders[i].([]any)
-					for j := range xa {
-						if xa[j] != nil {
-							isPlaceHolderEmpty = false
-							exitLoop = true
-							break
-						}
+			if xa, ok := placeHolders[i].([]any); ok {
+				for j := range xa {
+					if xa[j] != nil {
+						isPlaceHolderEmpty = false
+						exitLoop = true
+						break
 					}
 				}
 			} else {
+				// incl. a blob ([]byte)
 				isPlaceHolderEmpty = false
 			}
 		}
@@ -824,127 +862,180 @@ func (d *DB) Prepare(
 		//   correct:   "... where MyCol LIKE :AAA","%some string%"
 		//   malformed: ".... where MyCol LIKE '%?%'","some string"
 		//
-		C.sqlite3_finalize(ppStmt)
+		return errors.New("malformed parameter(s) detected in the sql statement")
+	}
 
-		return s, strings.TrimSpace(C.GoString(pzTail)),
-			errors.New("malformed parameter(s) detected in the sql statement")
+	if isPlaceHolderEmpty {
+		return nil
 	}
 
 	// bind the params
-	if !isPlaceHolderEmpty {
-		for i := range placeHolders {
-			C.sqlite3_reset(ppStmt)
-
-			p := placeHolders[i]
-
-			t := reflect.TypeOf(p)
-			if t != nil && t.Kind() == reflect.Slice {
-				// ** the args have been passed more than once to get here;
-				// ** the target value is inside the array.
-				if reflect.TypeOf(p).String() == "[]uint8" {
-					vx := p.([]uint8)
-					// this a BLOB; convert it to array of bytes
-					p = []byte(vx)
-
-				} else {
-					vx := p.([]any)
-					if len(vx) > 0 {
-						p = vx[0]
-					}
-				}
-			}
+	for i := range placeHolders {
+		C.sqlite3_reset(ppStmt)
 
-			switch v := p.(type) {
-			case nil:
-				// NULL
-				rc = C.sqlite3_bind_null(ppStmt, C.int(i+1))
+		p := placeHolders[i]
 
-			case uint8:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		// ** the args have been passed more than once to get here;
+		// ** the target value is inside the array.
+		if vx, ok := p.([]any); ok && len(vx) > 0 {
+			p = vx[0]
+		}
 
-			case uint:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		p, err = bindableValue(p, i)
+		if err != nil {
+			return err
+		}
 
-			case uint32:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		switch v := p.(type) {
+		case nil:
+			// NULL
+			rc = C.sqlite3_bind_null(ppStmt, C.int(i+1))
 
-			case uint64:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		case int64:
+			// INTEGER
+			rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
 
-			case int:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		case float64:
+			// REAL
+			rc = C.sqlite3_bind_double(ppStmt, C.int(i+1), C.double(v))
 
-			case int32:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+		case bool:
+			// 0 OR 1
+			// sqlite3 has no bool type; only 0 or 1
+			if v {
+				rc = C.sqlite3_bind_int(ppStmt, C.int(i+1), 1)
 
-			case int64:
-				// INTEGER
-				rc = C.sqlite3_bind_int64(ppStmt, C.int(i+1), C.sqlite3_int64(v))
+			} else {
+				rc = C.sqlite3_bind_int(ppStmt, C.int(i+1), 0)
+			}
 
-			case float32:
-				// REAL
-				rc = C.sqlite3_bind_double(ppStmt, C.int(i+1), C.double(v))
+		case time.Time:
+			// TEXT
+			// there is no date/time type in sqlite3; only text
+			rc = bindText(ppStmt, i+1, v.String())
 
-			case float64:
-				// REAL
-				rc = C.sqlite3_bind_double(ppStmt, C.int(i+1), C.double(v))
+		case string:
+			// TEXT
+			rc = bindText(ppStmt, i+1, v)
 
-			case bool:
-				// 0 OR 1
-				// sqlite3 has no bool type; only 0 or 1
-				if v {
-					rc = C.sqlite3_bind_int(ppStmt, C.int(i+1), 1)
+		case []byte:
+			// BLOB
+			// see if it's empty
+			if v == nil {
+				rc = C.sqlite3_bind_null(ppStmt, C.int(i+1))
 
-				} else {
-					rc = C.sqlite3_bind_int(ppStmt, C.int(i+1), 0)
-				}
+			} else {
+				rc = bindBlob(ppStmt, i+1, v)
+			}
 
-			case time.Time:
-				// TEXT
-				// there is no date/time type in sqlite3; only text
-				pChr = C.CString(p.(time.Time).String())
-				rc = C.sqlite3_bind_text(ppStmt, C.int(i+1), pChr, -1, nil)
-
-			case string:
-				// TEXT
-				pChr = C.CString(p.(string))
-				rc = C.sqlite3_bind_text(ppStmt, C.int(i+1), pChr, -1, nil)
-
-			case []byte:
-				// BLOB
-				// see if it's empty
-				if v == nil {
-					C.sqlite3_bind_null(ppStmt, C.int(i+1))
-
-				} else {
-					size := len(v)
-					rc = C.sqlite3_bind_blob(ppStmt, C.int(i+1), unsafe.Pointer(&v[0]), C.int(size), C.SQLITE_STATIC)
-				}
+		default:
+			return fmt.Errorf("unable to parse place-holder; type %v is not recognized", v)
+		}
 
-			default:
-				return s, C.GoString(pzTail),
-					fmt.Errorf("unable to parse place-holder; type %v is not recognized", v)
-			}
+		if rc != SQLITE_OK {
+			break
 		}
 	}
 
 	if rc != SQLITE_OK {
-		C.sqlite3_finalize(ppStmt)
-		err := getSQLiteErr(rc, d.DBHwnd)
-		if err.Error() == "column index out of range" {
+		err := newError(rc, s.db.DBHwnd)
+		err.SQL = s.SQL
+		if err.Code == SQLITE_RANGE {
 			// more meaning for the caller
-			err = errors.New("column does not exist")
+			err.Message = "column does not exist"
 		}
-		return s, strings.TrimSpace(C.GoString(pzTail)), err
+		return err
 	}
 
-	return s, strings.TrimSpace(C.GoString(pzTail)), nil
+	return nil
+}
+
+// bindableValue converts a place-holder value to one of the types
+// that sqlite3 stores; i.e. nil, int64, float64, bool, string, []byte
+// or time.Time. A driver.Valuer (e.g. sql.NullString) returns its own
+// value, a pointer is bound as its element (nil as NULL), and the types
+// that are based on the built-in ones are bound as such.
+func bindableValue(p any, i int) (any, error) {
+
+	if p == nil {
+		return nil, nil
+	}
+
+	if vr, ok := p.(driver.Valuer); ok {
+		rv := reflect.ValueOf(p)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+		v, err := vr.Value()
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %w", i, err)
+		}
+		if _, isValuer := v.(driver.Valuer); isValuer {
+			return nil, fmt.Errorf("arg %d: Value() of %T returned a driver.Valuer", i, p)
+		}
+		return bindableValue(v, i)
+	}
+
+	switch p.(type) {
+	case time.Time, []byte, string, int64, float64, bool:
+		return p, nil
+	}
+
+	rv := reflect.ValueOf(p)
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return bindableValue(rv.Elem().Interface(), i)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			// sqlite3 INTEGER is a signed 64-bit integer
+			return nil, fmt.Errorf("value %d of arg %d overflows INTEGER (int64)", u, i)
+		}
+		return int64(u), nil
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+
+	case reflect.Bool:
+		return rv.Bool(), nil
+
+	case reflect.String:
+		return rv.String(), nil
+
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	}
+
+	return p, nil
+}
+
+// bindText binds a string as TEXT; sqlite3 keeps its own
+// copy of the value, so the C string is freed right away.
+func bindText(ppStmt *C.sqlite3_stmt, indx int, v string) C.int {
+	pChr := C.CString(v)
+	defer C.free(unsafe.Pointer(pChr))
+
+	return C.bind_text_transient(ppStmt, C.int(indx), pChr, C.int(len(v)))
+}
+
+// bindBlob binds an array of bytes as BLOB; sqlite3 keeps
+// its own copy of the value.
+func bindBlob(ppStmt *C.sqlite3_stmt, indx int, v []byte) C.int {
+	if len(v) == 0 {
+		return C.sqlite3_bind_zeroblob(ppStmt, C.int(indx), 0)
+	}
+
+	return C.bind_blob_transient(ppStmt, C.int(indx), unsafe.Pointer(&v[0]), C.int(len(v)))
 }
 
 /*
@@ -1005,11 +1096,26 @@ func (d *DB) Query(query string, placeholders ...any) (*Rows, error) {
 */
 
 func (d *DB) Query(query string, placeHolders ...any) (*Rows, error) {
+	return d.readQuery(context.Background(), query, placeHolders...)
+}
 
-	QuerySeqNo++
+// QueryWithContext runs a query; the statement is aborted in
+// Rows.Next() when ctx is done, and Rows.Err() is ctx.Err().
+func (d *DB) QueryWithContext(ctx context.Context, query string, placeHolders ...any) (*Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	return d.readQuery(ctx, query, placeHolders...)
+}
+
+func (d *DB) query(ctx context.Context, query string, placeHolders ...any) (*Rows, error) {
+
+	defer d.requestStarted()()
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	type result struct {
 		rowsPtr Rows
@@ -1020,11 +1126,16 @@ func (d *DB) Query(query string, placeHolders ...any) (*Rows, error) {
 	go func() {
 		query = normalizeSQL(query)
 		var resw result
-		s, _, err := d.Prepare(query, placeHolders)
+		s, _, err := d.prepareCached(query, placeHolders)
+		if s == nil {
+			// Next() returns false
+			s = &Stmt{SQL: query, db: d, released: true}
+		}
 		var rows = Rows{
-			stmt:     &s,
+			stmt:     s,
 			db:       d,
 			colCount: int(C.sqlite3_column_count(s.cStmt)),
+			ctx:      ctx,
 		}
 		rows.intfc = &rows
 		resw.rowsPtr = rows
@@ -1034,8 +1145,6 @@ func (d *DB) Query(query string, placeHolders ...any) (*Rows, error) {
 	wrk = <-c
 	close(c)
 
-	QuerySeqNo--
-
 	return &wrk.rowsPtr, wrk.err
 }
 
@@ -1178,13 +1287,48 @@ func (rs *Rows) GetString(colName any) string {
 
 func (rs *Rows) Next() bool {
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	if rs == nil || rs.db == nil {
+		return false
+	}
 
-	if rs == nil || rs.stmt.cStmt == nil {
+	// the connection of the rows (see readQuery()) is returned
+	// to the pool after the deferred calls below, if Next() closes
+	// the rows; the pool may close the connection.
+	release := rs.release
+	rs.release = nil
+	defer func() {
+		if rs.closed && release != nil {
+			release()
+			return
+		}
+		rs.release = release
+	}()
+
+	if rs.txLock != nil {
+		// a step of a query on the writer connection
+		// waits for an open Tx; see readQuery().
+		rs.txLock.Lock()
+		defer rs.txLock.Unlock()
+	}
+
+	mu := rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rs.stmt == nil || rs.stmt.cStmt == nil || rs.stmt.released || rs.closed {
+		return false
+	}
+
+	if rs.ctx != nil && rs.ctx.Err() != nil {
+		rs.err = rs.ctx.Err()
+		rs.Close()
 		return false
 	}
 
+	// abort the step, if ctx is done
+	stop := rs.db.watchContext(rs.ctx)
+	defer stop()
+
 	type result struct {
 		stepResult int
 	}
@@ -1201,8 +1345,9 @@ func (rs *Rows) Next() bool {
 	close(c)
 
 	if next.stepResult != SQLITE_ROW {
-		C.sqlite3_finalize(rs.stmt.cStmt)
-		rs.stmt.released = true
+		if next.stepResult != SQLITE_DONE {
+			rs.err = rs.db.stepErr(rs.ctx, C.int(next.stepResult), rs.stmt.SQL)
+		}
 		rs.Close()
 		return false
 	}
@@ -1210,6 +1355,16 @@ func (rs *Rows) Next() bool {
 	return true
 }
 
+// Err returns the error, if any, that ended the
+// iteration of Next().
+func (rs *Rows) Err() error {
+	if rs == nil {
+		return nil
+	}
+
+	return rs.err
+}
+
 /*
 func (rs *Rows) next() bool {
 	if rs == nil || rs.stmt.cStmt == nil {
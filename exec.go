@@ -7,25 +7,22 @@ package gosqlite
 
 //#include <stdio.h>
 //#include <stdlib.h>
+//#include <stdint.h>
 //#include "sqlite3.h"
-// typedef int (*goFunPtr)();
-// char *queueID;
-// int set_queue_id(char *qid);
-// int set_queue_id(char *qid){
-//    queueID = qid;
-//    return 0;
-//}
-// int go_sqlite3_exec_callback(void *NotUsed, int argc, char **argv, char **azColName, char *queryID);
-// int getResult(void *NotUsed, int argc, char **argv, char **azColName);
-// int getResult(void *NotUsed, int argc, char **argv, char **azColName){
-//   go_sqlite3_exec_callback(NotUsed, argc, argv, azColName, queueID);
-//   return 0;
+// int go_sqlite3_exec_callback(uintptr_t h, int argc, char **argv, char **azColName);
+// static int getResult(void *h, int argc, char **argv, char **azColName){
+//   return go_sqlite3_exec_callback((uintptr_t)h, argc, argv, azColName);
+// }
+// static int exec_with_results(sqlite3 *db, const char *sql, uintptr_t h){
+//   return sqlite3_exec(db, sql, getResult, (void*)h, NULL);
 // }
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"runtime/cgo"
 	"strings"
 	"time"
 	"unsafe"
@@ -52,17 +49,8 @@ func (d *DB) ExecuteScalare(query string, placeHolders ...any) (any, error) {
 
 func (d *DB) executeNonQueryDo(query string, placeHolders []any) Result {
 	var resw Result
-	var tries int
 	query = strings.TrimSpace(query)
 
-	// UNDONE:  make these configurable or let the caller
-	// set them via optional args?
-	maxTries := 6
-
-	// on "database is locked"
-	// time to wait before trying again
-	// millSecToWait := time.Duration(150 * time.Millisecond)
-	millSecToWait := 150
 	sx := strings.ToUpper(removeDoubleSpace(query))
 	if d.Closed && !strings.HasPrefix(sx, "PRAGMA") &&
 		!strings.Contains(sx, "CREATE TEMP TABLE ") &&
@@ -76,53 +64,8 @@ func (d *DB) executeNonQueryDo(query string, placeHolders []any) Result {
 			return resw
 		}
 	}
-	query = normalizeSQL(query)
-	v := strings.Split(query, ";")
-	for i := range len(v) {
-		isDBLockedErr := false
-		tries = 0
-	tryAgain:
-		tries++
-		// It apears to run faster, if kept in
-		// this loop; vs a separate func!
-		sqlx := strings.TrimSpace(v[i])
-		if len(sqlx) < 3 {
-			continue
-		}
-		s, _, err := d.Prepare(sqlx, placeHolders)
-		if err == nil {
-			rc := C.sqlite3_step(s.cStmt)
-			if rc != SQLITE_DONE {
-				err = getSQLiteErr(rc, d.DBHwnd)
-				resw.rowsAffected = -1
-				resw.err = err
-			} else {
-				resw.rowsAffected = int64(C.sqlite3_changes(d.DBHwnd))
-				resw.lastInsertId = int64(C.sqlite3_last_insert_rowid(d.DBHwnd))
-				resw.err = nil
-			}
-			// release the statement
-			C.sqlite3_finalize(s.cStmt)
-		} else {
-			resw.err = err
-			isDBLockedErr = strings.Contains(resw.err.Error(), "database is locked")
-			// do not execute the next statement (if any)
-			if !isDBLockedErr {
-				break
-			}
-		}
-		if tries <= maxTries && resw.err != nil && isDBLockedErr {
-			//time.Sleep(millSecToWait * time.Millisecond)
-			C.sqlite3_sleep(C.int(millSecToWait))
-			goto tryAgain
-
-		} else if resw.err != nil && isDBLockedErr {
-			// do not conitue with the next statement (if any)
-			break
-		}
-	}
-
-	return resw
+	// the statements are split by sqlite3; see execScript().
+	return d.execDo(context.Background(), query, placeHolders...)
 }
 
 func (d *DB) ExecuteNonQuery(query string, placeholders ...any) (int64, error) {
@@ -133,122 +76,63 @@ func (d *DB) ExecuteNonQuery(query string, placeholders ...any) (int64, error) {
 	return res.rowsAffected, nil
 }
 
-// execWithResults sets the queryID and invokes the C.sqlite3_exec().
-func (d *DB) execWithResults(queryID string, sqlx string) {
-	var errmsg **C.char
-	var res C.int
-	var err error
+// execWithResults runs sqlx via C.sqlite3_exec() and returns
+// the rows collected by the exec callback.
+func (d *DB) execWithResults(sqlx string) ([]map[string]any, error) {
+	var rows []map[string]any
 
 	sqlxx := C.CString(sqlx)
 	defer C.free(unsafe.Pointer(sqlxx))
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	// the exec callback appends the rows via the handle
+	h := cgo.NewHandle(&rows)
+	defer h.Delete()
 
-	C.set_queue_id(C.CString(queryID))
-	res = C.sqlite3_exec(d.DBHwnd, sqlxx, C.goFunPtr(C.getResult), nil, errmsg)
-	err = getSQLiteErr(res, d.DBHwnd)
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
-	isDBLocked := false
-	if err != nil {
-		if err.Error() == "database is locked" {
-			isDBLocked = true
+	// retry, if the database is locked
+	err := d.retry(context.Background(), func() error {
+		rows = nil
+		res := C.exec_with_results(d.DBHwnd, sqlxx, C.uintptr_t(h))
+		return withSQL(getSQLiteErr(res, d.DBHwnd), sqlx)
+	})
 
-		} else if err.Error() == "out of memory" {
-			log.Fatal(err.Error())
-		}
+	if errors.Is(err, ErrNoMem) {
+		log.Fatal(err.Error())
 	}
 
-	for i := 0; i < len(mResultQueue); i++ {
-		if i >= len(mResultQueue) {
-			break
-		}
-		if i < len(mResultQueue) && mResultQueue[i].QueryID == queryID {
-			if isDBLocked {
-				if i >= len(mResultQueue) {
-					break
-				}
-				// reset
-				mResultQueue[i].Started = false
-				mResultQueue[i].Finished = false
-				mResultQueue[i].ExecResult = -1
-				mResultQueue[i].Err = nil
-			} else {
-				if i >= len(mResultQueue) {
-					break
-				}
-				// if there is an error, there will be more
-				// callbacks from the C.sqlite3 for this query.
-				mResultQueue[i].Err = err
-				mResultQueue[i].ExecResult = int(res)
-				mResultQueue[i].TimeEnded = time.Now()
-				mResultQueue[i].Processed = true
-
-				// after other processes read the
-				// resultset, they may remove the element
-				// rapidly from the array; so it may not
-				// exist by the time this statement is reached.
-				// so, it is important to set the Finshed variable last.
-				mResultQueue[i].Finished = true
-				return
-			}
-			break
-		}
-	}
+	return rows, err
 }
 
 func (d *DB) Execute(sqlx string) (int64, error) {
 
-	var wrk Result
-
 	if d.Closed {
 		return -1, errors.New("database is not open")
 	}
 
-	ExecuteSeqNo++
-
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
-
-	c := make(chan Result)
-	go func() {
-		var resw Result
-		if d.DBHwnd == nil {
-			resw.rowsAffected = -1
-			resw.err = errors.New("database no longer available")
-		} else {
-			// execute sql statement(s)
-			sqlx = normalizeSQL(sqlx)
-			v := strings.Split(sqlx, ";")
-			for i := range v {
-				query := strings.TrimSpace(v[i])
-				if len(query) == 0 {
-					continue
-				}
-
-				sqlxx := C.CString(query)
-				defer C.free(unsafe.Pointer(sqlxx))
-
-				rc := C.sqlite3_exec(d.DBHwnd, sqlxx, C.goFunPtr(C.getResult), nil, nil)
-				if rc != SQLITE_OK {
-					err := getSQLiteErr(rc, d.DBHwnd)
-					resw.rowsAffected = -1
-					resw.err = err
-				} else {
-					resw.rowsAffected = int64(C.sqlite3_changes(d.DBHwnd))
-					resw.lastInsertId = int64(C.sqlite3_last_insert_rowid(d.DBHwnd))
-					resw.err = nil
-				}
-			}
-		}
-		c <- resw
-	}()
-	wrk = <-c
-	close(c)
+	// see ConnPool.MaxConcurrentRequests
+	leave, err := d.enterRequest(context.Background())
+	if err != nil {
+		return -1, err
+	}
+	defer leave()
 
-	ExecuteSeqNo--
+	// other writes wait for the statements; see doExec().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 
-	return wrk.rowsAffected, wrk.err
+	// execute sql statement(s); see ExecScript()
+	res, err := d.execScript(context.Background(), sqlx, nil)
+	if err != nil {
+		return -1, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[len(res)-1].rowsAffected, nil
 }
 
 func (d *DB) GetResultSet(sqlx string) QueryResult {
@@ -260,23 +144,31 @@ func (d *DB) GetResultSet(sqlx string) QueryResult {
 		return q
 	}
 
-	// keep track of connections
-	d.getResultSetConnections++
-	defer d.decrementActiveConn()
-
-	// global lock for the connSeq increment
-	mCMutex.Lock()
-	GetResultSetSeqNo++
-	queryID := fmt.Sprintf("%d_%s", GetResultSetSeqNo, d.Name)
-	mResultQueue = append(mResultQueue, queryResult{
-		SeqNo:   GetResultSetSeqNo,
-		QueryID: queryID, SQLStmt: sqlx,
-		QueryType: DATA_TABLE})
-	mCMutex.Unlock()
-
-	if d.getResultSetConnections < 2 {
-		d.processRequestQueue()
+	defer d.requestStarted()()
+
+	// other writes wait for the statements; see doExec().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	q.SeqNo = d.nextSeqNo()
+	q.QueryID = fmt.Sprintf("%d_%s", q.SeqNo, d.Name)
+	q.TimeStarted = time.Now()
+	q.ResultTable, q.Err = d.execWithResults(sqlx)
+	q.TimeEnded = time.Now()
+
+	if len(q.ResultTable) > 0 {
+		colLen := len(q.ResultTable[0])
+		q.Columns = make([]Column, colLen)
+		p := 0
+		for k, v := range q.ResultTable[0] {
+			q.Columns[p] = Column{
+				Name:     k,
+				Ordinal:  p,
+				DataType: GetSQLiteDataType(v),
+			}
+			p++
+		}
 	}
 
-	return d.waiForQueryResult(&queryID, 0)
+	return q
 }
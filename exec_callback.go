@@ -5,18 +5,22 @@
 
 package gosqlite
 
+//#include <stdint.h>
 import "C"
 import (
+	"runtime/cgo"
 	"strconv"
 	"unsafe"
 )
 
-// go_sqlite3_exec_callback is a callback func for Execute().
+// go_sqlite3_exec_callback is the callback of C.sqlite3_exec() for
+// GetResultSet(); it is called once per row. h is the handle of the
+// rows of the query; see execWithResults().
 //
 //export go_sqlite3_exec_callback
-func go_sqlite3_exec_callback(NotUsed *C.void, argc C.int, argv **C.char, azColName **C.char, queryID *C.char) {
+func go_sqlite3_exec_callback(h C.uintptr_t, argc C.int, argv **C.char, azColName **C.char) C.int {
 
-	qryID := C.GoString(queryID)
+	rows := cgo.Handle(h).Value().(*[]map[string]any)
 	size := int(argc)
 	vals := (*[1 << 30]*C.char)(unsafe.Pointer(argv))[:size:size]
 	cols := (*[1 << 30]*C.char)(unsafe.Pointer(azColName))[:size:size]
@@ -43,11 +47,7 @@ func go_sqlite3_exec_callback(NotUsed *C.void, argc C.int, argv **C.char, azColN
 		mRow[C.GoString(cols[i])] = v
 	}
 
-	l := len(mResultQueue)
-	for i := range l {
-		if mResultQueue[i].QueryID == qryID {
-			mResultQueue[i].Result = append(mResultQueue[i].Result, mRow)
-			return
-		}
-	}
+	*rows = append(*rows, mRow)
+
+	return 0
 }
@@ -0,0 +1,369 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+// void go_sqlite3_func(sqlite3_context *ctx, uintptr_t h, int argc, sqlite3_value **argv);
+// void go_sqlite3_destroy(uintptr_t h);
+// static void scalar_func(sqlite3_context *ctx, int argc, sqlite3_value **argv){
+//   go_sqlite3_func(ctx, (uintptr_t)sqlite3_user_data(ctx), argc, argv);
+// }
+// static void destroy_func(void *h){
+//   go_sqlite3_destroy((uintptr_t)h);
+// }
+// static int create_function(sqlite3 *db, const char *name, int nArg, int flags, uintptr_t h){
+//   return sqlite3_create_function_v2(db, name, nArg, SQLITE_UTF8|flags, (void*)h, scalar_func, NULL, NULL, destroy_func);
+// }
+// static void result_text(sqlite3_context *ctx, const char *z, int n){
+//   sqlite3_result_text(ctx, z, n, SQLITE_TRANSIENT);
+// }
+// static void result_blob(sqlite3_context *ctx, const void *z, int n){
+//   sqlite3_result_blob(ctx, z, n, SQLITE_TRANSIENT);
+// }
+import "C"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/cgo"
+	"time"
+	"unsafe"
+)
+
+// FuncOptions are the options of DB.RegisterFunc().
+// See: https://www.sqlite.org/c3ref/c_deterministic.html
+type FuncOptions struct {
+	// Deterministic is for a function that always returns the same
+	// result for the same args; so that sqlite3 can optimize it, and
+	// it can be used in indexes, CHECK constraints, etc.
+	Deterministic bool
+
+	// DirectOnly prevents the function from being used in triggers,
+	// views, CHECK constraints, etc. (i.e. in the schema); it can
+	// only be called from top-level SQL.
+	DirectOnly bool
+}
+
+// flags are the SQLITE_DETERMINISTIC and SQLITE_DIRECTONLY
+// flags of the options.
+func (o FuncOptions) flags() C.int {
+	var flags C.int
+	if o.Deterministic {
+		flags |= C.SQLITE_DETERMINISTIC
+	}
+	if o.DirectOnly {
+		flags |= C.SQLITE_DIRECTONLY
+	}
+
+	return flags
+}
+
+// goFunc is a Go function called from SQL; see RegisterFunc().
+type goFunc struct {
+	name   string
+	fn     reflect.Value
+	args   []reflect.Type // the last one is the element type, if variadic
+	vararg bool
+	hasErr bool
+}
+
+var (
+	errorType   = reflect.TypeFor[error]()
+	scannerType = reflect.TypeFor[sql.Scanner]()
+)
+
+// RegisterFunc registers a Go function as an SQL scalar function;
+// fn is a func with any number of args (incl. variadic) that returns
+// a value, or a value and an error. The args are converted from the
+// SQL values as by Rows.Scan() (i.e. int*, uint*, float*, bool, string,
+// []byte, time.Time, sql.Scanner, ...); an any arg is int64, float64,
+// string, []byte or nil, and a pointer arg is nil for NULL (other args
+// are their zero value). The result is converted as a place holder
+// value is bound (see Exec()). An error returned by fn (or a panic) is
+// the error of the SQL statement. e.g.
+//
+//	err := db.RegisterFunc("slugify", func(s string) string {
+//		return strings.ToLower(strings.ReplaceAll(s, " ", "-"))
+//	}, gosqlite.FuncOptions{Deterministic: true})
+//	...
+//	rows, err := db.Query("select slugify(title) from post")
+//
+// The function is registered on all connections of the database (incl.
+// the readers of the pool), so fn must be safe for concurrent use. A
+// function with the same name and number of args is replaced.
+// See: https://www.sqlite.org/c3ref/create_function.html
+func (d *DB) RegisterFunc(name string, fn any, opts FuncOptions) error {
+	if d == nil || d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+
+	f, err := newGoFunc(name, fn)
+	if err != nil {
+		return err
+	}
+
+	nArg := len(f.args)
+	if f.vararg {
+		nArg = -1
+	}
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return d.registry.add(d.DBHwnd, fmt.Sprintf("func/%s/%d", name, nArg), func(hwnd *C.sqlite3) error {
+		return createFunction(hwnd, name, nArg, opts.flags(), f)
+	})
+}
+
+// newGoFunc checks the signature of a function.
+func newGoFunc(name string, fn any) (*goFunc, error) {
+	if name == "" {
+		return nil, errors.New("function name is empty")
+	}
+	if len(name) > 255 {
+		return nil, errors.New("function name is longer than 255 bytes")
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("%s: %T is not a func", name, fn)
+	}
+
+	t := v.Type()
+	f := goFunc{name: name, fn: v, vararg: t.IsVariadic()}
+
+	if t.NumIn() > 127 {
+		return nil, fmt.Errorf("%s: more than 127 args", name)
+	}
+	for i := range t.NumIn() {
+		at := t.In(i)
+		if f.vararg && i == t.NumIn()-1 {
+			at = at.Elem()
+		}
+		if !funcArgSupported(at) {
+			return nil, fmt.Errorf("%s: arg %d: type %s is not supported", name, i+1, at)
+		}
+		f.args = append(f.args, at)
+	}
+
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		f.hasErr = true
+	default:
+		return nil, fmt.Errorf("%s: a func that returns a value, or a value and an error expected", name)
+	}
+
+	return &f, nil
+}
+
+// funcArgSupported reports whether an SQL value can be
+// converted to the type of an arg.
+func funcArgSupported(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return t.NumMethod() == 0
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() || reflect.PointerTo(t).Implements(scannerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+
+	return false
+}
+
+// createFunction registers a function on a connection; a new
+// handle is made per connection, and deleted by sqlite3 (see
+// go_sqlite3_destroy()) when the function or the connection is
+// closed.
+func createFunction(hwnd *C.sqlite3, name string, nArg int, flags C.int, f *goFunc) error {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	h := cgo.NewHandle(f)
+
+	// h is deleted by sqlite3, incl. on error
+	rc := C.create_function(hwnd, cName, C.int(nArg), flags, C.uintptr_t(h))
+	if rc != SQLITE_OK {
+		return getSQLiteErr(rc, hwnd)
+	}
+
+	return nil
+}
+
+// call calls the Go function with the args of the SQL function,
+// and sets its result.
+func (f *goFunc) call(ctx *C.sqlite3_context, argv []*C.sqlite3_value) {
+	defer func() {
+		if r := recover(); r != nil {
+			resultError(ctx, fmt.Errorf("%s: panic: %v", f.name, r))
+		}
+	}()
+
+	args := make([]reflect.Value, len(argv))
+	for i := range argv {
+		t := f.args[min(i, len(f.args)-1)]
+
+		v, err := funcArg(argv[i], t, i+1)
+		if err != nil {
+			resultError(ctx, fmt.Errorf("%s: %w", f.name, err))
+			return
+		}
+		args[i] = v
+	}
+
+	out := f.fn.Call(args)
+	if f.hasErr && !out[1].IsNil() {
+		resultError(ctx, out[1].Interface().(error))
+		return
+	}
+
+	if err := setResult(ctx, out[0].Interface()); err != nil {
+		resultError(ctx, fmt.Errorf("%s: %w", f.name, err))
+	}
+}
+
+// sqlValue is the value of an SQL arg; i.e. int64, float64,
+// string, []byte or nil, as by getStmtColVal().
+func sqlValue(v *C.sqlite3_value) any {
+	switch C.sqlite3_value_type(v) {
+	case SQLITE_INTEGER:
+		return int64(C.sqlite3_value_int64(v))
+
+	case SQLITE_FLOAT:
+		return float64(C.sqlite3_value_double(v))
+
+	case SQLITE_TEXT:
+		n := C.sqlite3_value_bytes(v)
+		return C.GoStringN((*C.char)(unsafe.Pointer(C.sqlite3_value_text(v))), n)
+
+	case SQLITE_BLOB:
+		n := C.sqlite3_value_bytes(v)
+		b := C.sqlite3_value_blob(v)
+		if b == nil {
+			return []byte{}
+		}
+		return C.GoBytes(b, n)
+	}
+
+	// NULL
+	return nil
+}
+
+// funcArg converts an SQL value to the type of an arg; i is the
+// position of the arg (starting at 1).
+func funcArg(v *C.sqlite3_value, t reflect.Type, i int) (reflect.Value, error) {
+	val := sqlValue(v)
+
+	if t.Kind() == reflect.Interface {
+		if val == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(val), nil
+	}
+
+	if val == nil {
+		if t.Kind() == reflect.Pointer || !reflect.PointerTo(t).Implements(scannerType) {
+			// nil, or the zero value
+			return reflect.Zero(t), nil
+		}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		p := reflect.New(t.Elem())
+		if err := assignColVal(val, p.Interface(), i); err != nil {
+			return reflect.Value{}, err
+		}
+		return p, nil
+	}
+
+	p := reflect.New(t)
+	if err := assignColVal(val, p.Interface(), i); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return p.Elem(), nil
+}
+
+// setResult sets the result of an SQL function; the value
+// is converted as a place holder value (see bindableValue()).
+func setResult(ctx *C.sqlite3_context, v any) error {
+	v, err := bindableValue(v, 0)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		C.sqlite3_result_null(ctx)
+
+	case int64:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+
+	case float64:
+		C.sqlite3_result_double(ctx, C.double(v))
+
+	case bool:
+		// sqlite3 has no bool type; only 0 or 1
+		if v {
+			C.sqlite3_result_int64(ctx, 1)
+		} else {
+			C.sqlite3_result_int64(ctx, 0)
+		}
+
+	case time.Time:
+		// there is no date/time type in sqlite3; only text
+		resultText(ctx, v.String())
+
+	case string:
+		resultText(ctx, v)
+
+	case []byte:
+		if v == nil {
+			C.sqlite3_result_null(ctx)
+		} else if len(v) == 0 {
+			C.sqlite3_result_zeroblob(ctx, 0)
+		} else {
+			C.result_blob(ctx, unsafe.Pointer(&v[0]), C.int(len(v)))
+		}
+
+	default:
+		return fmt.Errorf("unable to set the result; type %T is not recognized", v)
+	}
+
+	return nil
+}
+
+// resultText sets a TEXT result; sqlite3 makes a copy of it.
+func resultText(ctx *C.sqlite3_context, s string) {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+
+	C.result_text(ctx, cs, C.int(len(s)))
+}
+
+// resultError makes the SQL statement fail with err.
+func resultError(ctx *C.sqlite3_context, err error) {
+	msg := err.Error()
+	cs := C.CString(msg)
+	defer C.free(unsafe.Pointer(cs))
+
+	C.sqlite3_result_error(ctx, cs, C.int(len(msg)))
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// go_sqlite3_func is the xFunc of a function registered by
+// RegisterFunc(); h is the handle of its goFunc.
+//
+//export go_sqlite3_func
+func go_sqlite3_func(ctx *C.sqlite3_context, h C.uintptr_t, argc C.int, argv **C.sqlite3_value) {
+	f := cgo.Handle(h).Value().(*goFunc)
+	f.call(ctx, unsafe.Slice(argv, int(argc)))
+}
+
+// go_sqlite3_destroy deletes the handle of a function (or of an
+// aggregate, a collation or a module), when it or the connection
+// is closed.
+//
+//export go_sqlite3_destroy
+func go_sqlite3_destroy(h C.uintptr_t) {
+	cgo.Handle(h).Delete()
+}
+
+// go_sqlite3_agg_step is the xStep of an aggregate registered by
+// RegisterAggregate(); h is the handle of its goAggregate.
+//
+//export go_sqlite3_agg_step
+func go_sqlite3_agg_step(ctx *C.sqlite3_context, h C.uintptr_t, argc C.int, argv **C.sqlite3_value) {
+	a := cgo.Handle(h).Value().(*goAggregate)
+	a.step(ctx, unsafe.Slice(argv, int(argc)), false)
+}
+
+// go_sqlite3_agg_inverse is the xInverse of a window function.
+//
+//export go_sqlite3_agg_inverse
+func go_sqlite3_agg_inverse(ctx *C.sqlite3_context, h C.uintptr_t, argc C.int, argv **C.sqlite3_value) {
+	a := cgo.Handle(h).Value().(*goAggregate)
+	a.step(ctx, unsafe.Slice(argv, int(argc)), true)
+}
+
+// go_sqlite3_agg_final is the xFinal of an aggregate.
+//
+//export go_sqlite3_agg_final
+func go_sqlite3_agg_final(ctx *C.sqlite3_context, h C.uintptr_t) {
+	a := cgo.Handle(h).Value().(*goAggregate)
+	a.final(ctx, true)
+}
+
+// go_sqlite3_agg_value is the xValue of a window function.
+//
+//export go_sqlite3_agg_value
+func go_sqlite3_agg_value(ctx *C.sqlite3_context, h C.uintptr_t) {
+	a := cgo.Handle(h).Value().(*goAggregate)
+	a.final(ctx, false)
+}
+
+// go_sqlite3_collation is the compare func of a collation registered
+// by RegisterCollation(); h is the handle of its goCollation.
+//
+//export go_sqlite3_collation
+func go_sqlite3_collation(h C.uintptr_t, na C.int, a unsafe.Pointer, nb C.int, b unsafe.Pointer) C.int {
+	c := cgo.Handle(h).Value().(*goCollation)
+	n := c.compare(C.GoStringN((*C.char)(a), na), C.GoStringN((*C.char)(b), nb))
+
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
+
+// go_sqlite3_vtab_connect is the xCreate and xConnect of a module
+// registered by RegisterModule(); h is the handle of its goModule,
+// and the handle of the new goVTab is set to pVTab.
+//
+//export go_sqlite3_vtab_connect
+func go_sqlite3_vtab_connect(db *C.sqlite3, h C.uintptr_t, argc C.int, argv **C.char, pVTab *C.uintptr_t, pzErr **C.char) C.int {
+	m := cgo.Handle(h).Value().(*goModule)
+
+	args := make([]string, int(argc))
+	for i, s := range unsafe.Slice(argv, int(argc)) {
+		args[i] = C.GoString(s)
+	}
+
+	return m.connect(db, args, pVTab, pzErr)
+}
+
+// go_sqlite3_vtab_disconnect is the xDisconnect and xDestroy
+// of a table; h is the handle of its goVTab.
+//
+//export go_sqlite3_vtab_disconnect
+func go_sqlite3_vtab_disconnect(h C.uintptr_t, destroy C.int) C.int {
+	t := cgo.Handle(h).Value().(*goVTab)
+	return t.disconnect(cgo.Handle(h), destroy != 0)
+}
+
+// go_sqlite3_vtab_best_index is the xBestIndex of a table.
+//
+//export go_sqlite3_vtab_best_index
+func go_sqlite3_vtab_best_index(h C.uintptr_t, vt *C.sqlite3_vtab, info *C.sqlite3_index_info) C.int {
+	t := cgo.Handle(h).Value().(*goVTab)
+	return t.bestIndex(vt, info)
+}
+
+// go_sqlite3_vtab_open is the xOpen of a table; the handle
+// of the new goCursor is set to pCur.
+//
+//export go_sqlite3_vtab_open
+func go_sqlite3_vtab_open(h C.uintptr_t, vt *C.sqlite3_vtab, pCur *C.uintptr_t) C.int {
+	t := cgo.Handle(h).Value().(*goVTab)
+	return t.open(vt, pCur)
+}
+
+// go_sqlite3_vtab_update is the xUpdate of a table.
+//
+//export go_sqlite3_vtab_update
+func go_sqlite3_vtab_update(h C.uintptr_t, vt *C.sqlite3_vtab, argc C.int, argv **C.sqlite3_value, pRowid *C.sqlite3_int64) C.int {
+	t := cgo.Handle(h).Value().(*goVTab)
+	return t.update(vt, unsafe.Slice(argv, int(argc)), pRowid)
+}
+
+// go_sqlite3_vtab_close is the xClose of a cursor; c is the
+// handle of its goCursor.
+//
+//export go_sqlite3_vtab_close
+func go_sqlite3_vtab_close(c C.uintptr_t) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+	return cur.close(cgo.Handle(c))
+}
+
+// go_sqlite3_vtab_filter is the xFilter of a cursor.
+//
+//export go_sqlite3_vtab_filter
+func go_sqlite3_vtab_filter(c C.uintptr_t, vt *C.sqlite3_vtab, idxNum C.int, idxStr *C.char, argc C.int, argv **C.sqlite3_value) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+
+	var s string
+	if idxStr != nil {
+		s = C.GoString(idxStr)
+	}
+
+	return cur.filter(vt, int(idxNum), s, unsafe.Slice(argv, int(argc)))
+}
+
+// go_sqlite3_vtab_next is the xNext of a cursor.
+//
+//export go_sqlite3_vtab_next
+func go_sqlite3_vtab_next(c C.uintptr_t, vt *C.sqlite3_vtab) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+	return cur.next(vt)
+}
+
+// go_sqlite3_vtab_eof is the xEof of a cursor.
+//
+//export go_sqlite3_vtab_eof
+func go_sqlite3_vtab_eof(c C.uintptr_t) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+	return cur.eof()
+}
+
+// go_sqlite3_vtab_column is the xColumn of a cursor.
+//
+//export go_sqlite3_vtab_column
+func go_sqlite3_vtab_column(c C.uintptr_t, vt *C.sqlite3_vtab, ctx *C.sqlite3_context, i C.int) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+	return cur.column(vt, ctx, int(i))
+}
+
+// go_sqlite3_vtab_rowid is the xRowid of a cursor.
+//
+//export go_sqlite3_vtab_rowid
+func go_sqlite3_vtab_rowid(c C.uintptr_t, vt *C.sqlite3_vtab, pRowid *C.sqlite3_int64) C.int {
+	cur := cgo.Handle(c).Value().(*goCursor)
+	return cur.rowid(vt, pRowid)
+}
+
+// go_sqlite3_update_hook is the update hook of a connection; h is
+// the handle of the hookState of its DB. See OnUpdate().
+//
+//export go_sqlite3_update_hook
+func go_sqlite3_update_hook(h C.uintptr_t, op C.int, db *C.char, table *C.char, rowid C.sqlite3_int64) {
+	s := cgo.Handle(h).Value().(*hookState)
+	s.update(Change{Op: Op(op), DB: C.GoString(db), Table: C.GoString(table), Rowid: int64(rowid)})
+}
+
+// go_sqlite3_commit_hook is the commit hook of a connection; a
+// non-zero return rolls back the transaction. See OnCommit().
+//
+//export go_sqlite3_commit_hook
+func go_sqlite3_commit_hook(h C.uintptr_t) C.int {
+	s := cgo.Handle(h).Value().(*hookState)
+	if s.commit() {
+		return 0
+	}
+
+	return 1
+}
+
+// go_sqlite3_rollback_hook is the rollback hook of a connection.
+// See OnRollback().
+//
+//export go_sqlite3_rollback_hook
+func go_sqlite3_rollback_hook(h C.uintptr_t) {
+	s := cgo.Handle(h).Value().(*hookState)
+	s.rollback()
+}
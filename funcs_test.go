@@ -0,0 +1,115 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	db := openTestDB(t, true)
+
+	funcs := map[string]any{
+		"slugify": func(s string) string {
+			return strings.ToLower(strings.ReplaceAll(s, " ", "-"))
+		},
+		"plus": func(a int32, b float64) float64 { return float64(a) + b },
+		"total": func(xs ...int64) int64 {
+			var n int64
+			for _, x := range xs {
+				n += x
+			}
+			return n
+		},
+		"isnil": func(p *string) bool { return p == nil },
+		"fail": func(s string) (string, error) {
+			if s == "" {
+				return "", errors.New("empty string")
+			}
+			return s, nil
+		},
+		"boom": func() int { panic("boom") },
+	}
+	for name, fn := range funcs {
+		if err := db.RegisterFunc(name, fn, FuncOptions{Deterministic: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := QueryOne[string](db, "select slugify('Hello Big World')")
+	if err != nil || s != "hello-big-world" {
+		t.Fatalf("got %q, %v", s, err)
+	}
+	f, err := QueryOne[float64](db, "select plus(?, ?)", 2, 0.5)
+	if err != nil || f != 2.5 {
+		t.Fatalf("got %v, %v", f, err)
+	}
+	for sqlx, want := range map[string]int64{
+		"select total()":                 0,
+		"select total(1, 2, 3)":          6,
+		"select isnil(null)":             1,
+		"select isnil('a')":              0,
+		"select length(fail('abc'))":     3,
+		"select total(1) + total(2) * 2": 5,
+	} {
+		if n := count(t, db, sqlx); n != want {
+			t.Fatalf("%s: got %d, want %d", sqlx, n, want)
+		}
+	}
+
+	if _, err := QueryOne[string](db, "select fail('')"); err == nil || !strings.Contains(err.Error(), "empty string") {
+		t.Fatalf("got %v, want the error of the function", err)
+	}
+	if _, err := QueryOne[int64](db, "select boom()"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("got %v, want the panic of the function", err)
+	}
+
+	// a deterministic function can be used in an index
+	mustExec(t, db, "create table post(title text)")
+	mustExec(t, db, "create index post_slug on post(slugify(title))")
+	mustExec(t, db, "insert into post values('A Title')")
+	if n := count(t, db, "select count(*) from post where slugify(title) = 'a-title'"); n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+}
+
+func TestRegisterFuncDirectOnly(t *testing.T) {
+	db := openTestDB(t, false)
+
+	if err := db.RegisterFunc("secret", func() string { return "x" }, FuncOptions{DirectOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := QueryOne[string](db, "select secret()"); err != nil || s != "x" {
+		t.Fatalf("got %q, %v", s, err)
+	}
+
+	mustExec(t, db, "create view v as select secret() as s")
+	if _, err := QueryOne[string](db, "select s from v"); err == nil {
+		t.Fatal("no error for a direct-only function in a view")
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	db := openTestDB(t, false)
+
+	for _, fn := range []any{
+		nil,
+		"not a func",
+		func() {},
+		func() error { return nil },
+		func(m map[string]int) int { return 0 },
+		func() (int, int) { return 0, 0 },
+	} {
+		if err := db.RegisterFunc("f", fn, FuncOptions{}); err == nil {
+			t.Fatalf("no error for %T", fn)
+		}
+	}
+	if err := db.RegisterFunc("", func() int { return 0 }, FuncOptions{}); err == nil {
+		t.Fatal("no error for an empty name")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -61,8 +62,6 @@ func (d *DB) DataTableToJSON(dt DataTable) string {
 	}
 
 	var wrkRes string
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
 
 	c := make(chan string)
 	go func() {
@@ -255,58 +254,47 @@ func (d *DB) GetDataTableAsync(callback func(*DataTable), query string, placeHol
 		return &wrkRes, wrkRes.Err
 	}
 
-	GetDataTableSeqNo++
+	defer d.requestStarted()()
+	seqNo := d.nextSeqNo()
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	// on the writer connection; it waits for an open Tx.
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	c := make(chan DataTable)
 	go func() {
 		var wrk DataTable
 		wrk.db = d
-		wrk.SeqNo = GetDataTableSeqNo
+		wrk.SeqNo = int(seqNo)
 		wrk.TimeStarted = time.Now()
 		query = normalizeSQL(query)
-		s, _, err := d.Prepare(query, placeHolders)
+		s, _, err := d.prepareCached(query, placeHolders)
 		if err == nil {
 			wrk.Name = getTableNameFromSQLQuery(query)
-			colCnt := int(C.sqlite3_column_count(s.cStmt))
-			for i := 0; i < colCnt; i++ {
-				// a column name from the query (cStmt) could be
-				// an alias, which would not be in the schema.
-				colName := C.GoString(C.sqlite3_column_name(s.cStmt, C.int(i)))
-				wrk.Columns = append(wrk.Columns, Column{
-					Name: colName, Ordinal: i})
-			}
-
-			colDesc := d.GetTableColumns(wrk.Name)
-
-			// set the columns first.
-			for i := 0; i < len(wrk.Columns); i++ {
-				for k := 0; k < len(colDesc); k++ {
-					if colDesc[k].Name == wrk.Columns[i].Name {
-						wrk.Columns[i] = colDesc[k]
-						break
-					}
-				}
-				// ordinal is relative to the columns
-				// in the query; replace it.
-				wrk.Columns[i].Ordinal = i
-			}
+			// the source table/column of every column; incl.
+			// aliases and the columns of joined tables.
+			wrk.Columns = d.stmtColumns(s)
 			// fetch rows
 			for {
 				rc := C.sqlite3_step(s.cStmt)
 				if rc != SQLITE_ROW {
+					if rc != SQLITE_DONE {
+						wrk.Err = d.stepErr(context.Background(), rc, query)
+					}
 					break
 				}
 				m := make(map[string]any, 1)
 				for i := 0; i < len(wrk.Columns); i++ {
-					m[wrk.Columns[i].Name] = d.getStmtColVal(&s, i)
+					m[wrk.Columns[i].Name] = d.getStmtColVal(s, i)
 
-					// If the DataType is empty, the column-name
-					// must be an alias (e.g. _rowid_ as RowID);
+					// If the DataType is empty, the column
+					// must be an expression (e.g. count(*));
 					// get the data-type from the value.
-					if i == 0 && wrk.Columns[i].DataType == "" {
+					if wrk.Columns[i].DataType == "" {
 						t := fmt.Sprintf("%T", m[wrk.Columns[i].Name])
 						switch t {
 						case "string":
@@ -325,7 +313,9 @@ func (d *DB) GetDataTableAsync(callback func(*DataTable), query string, placeHol
 				}
 				wrk.Rows = append(wrk.Rows, m)
 			}
-			C.sqlite3_finalize(s.cStmt)
+			d.releaseStmt(s)
+		} else {
+			wrk.Err = err
 		}
 		c <- wrk
 	}()
@@ -334,8 +324,6 @@ func (d *DB) GetDataTableAsync(callback func(*DataTable), query string, placeHol
 
 	wrkRes.TimeEnded = time.Now()
 
-	GetDataTableSeqNo--
-
 	if callback != nil {
 		callback(&wrkRes)
 	}
@@ -356,6 +344,9 @@ type DataTableOp struct {
 	MillSecToWait int
 
 	db *DB
+
+	// ctx aborts the running statement, when done.
+	ctx context.Context
 }
 
 // IDataTableOp gets resuls of a DataTable;
@@ -370,18 +361,13 @@ type IDataTableOp interface {
 func (d *DataTableOp) getWithContext(ctx context.Context, query string, placeHolders ...any) (*DataTable, error) {
 	var wrkRes DataTable
 
-	c := make(chan DataTable)
-	go func(ctx context.Context) {
-		select {
-		case c <- d.get(query, placeHolders...):
-		case <-ctx.Done():
-			// should not get here, if func succeeds
-			wrkRes.Err = ctx.Err()
-			c <- wrkRes
-		}
-	}(ctx)
-	wrkRes = <-c
-	close(c)
+	if err := ctx.Err(); err != nil {
+		wrkRes.Err = err
+		return &wrkRes, err
+	}
+
+	d.ctx = ctx
+	wrkRes = d.get(query, placeHolders...)
 
 	wrkRes.TimeEnded = time.Now()
 
@@ -422,8 +408,8 @@ func (d *DataTableOp) get(query string, placeHolders ...any) DataTable {
 		}
 	}
 
-	// now  add the global counter
-	GetDataTableSeqNo++
+	defer d.db.requestStarted()()
+	seqNo := d.db.nextSeqNo()
 
 	c := make(chan DataTable)
 	go func() {
@@ -431,67 +417,50 @@ func (d *DataTableOp) get(query string, placeHolders ...any) DataTable {
 		query = normalizeSQL(query)
 		// var isDBLockedErr bool
 		wrk.db = d.db
-		wrk.SeqNo = GetDataTableSeqNo
+		wrk.SeqNo = int(seqNo)
 		// tries := 0
 		wrk.TimeStarted = time.Now()
 		//tryAgain:
 		//tries++
-		s, pzTail, err := d.db.Prepare(query, placeHolders)
+		s, pzTail, err := d.db.prepareCached(query, placeHolders)
 		if err == nil {
 			wrk.SQLTail = pzTail
 			wrk.Name = getTableNameFromSQLQuery(query)
-			colCnt := int(C.sqlite3_column_count(s.cStmt))
-			for i := range colCnt {
-				// a column name from the query (cStmt) could be
-				// an alias, which would not be in the schema.
-				colName := C.GoString(C.sqlite3_column_name(s.cStmt, C.int(i)))
-				wrk.Columns = append(wrk.Columns, Column{
-					Name: colName, Ordinal: i})
-			}
+			// the source table/column of every column; incl.
+			// aliases and the columns of joined tables.
+			wrk.Columns = d.db.stmtColumns(s)
 
-			colDesc := d.db.GetTableColumns(wrk.Name)
+			// abort the running statement, if ctx is done
+			stop := d.db.watchContext(d.ctx)
 
-			// set the columns first; dynamic + IsPrimaryKey
-			for i := range wrk.Columns {
-				for k := range colDesc {
-					if colDesc[k].Name == wrk.Columns[i].Name {
-						wrk.Columns[i] = colDesc[k]
-						break
-					}
-				}
-				// ordinal is relative to the columns
-				// in the query; replace it.
-				wrk.Columns[i].Ordinal = i
-			}
 			// fetch the rows
-			hitTest := false
 			for {
 				rc := C.sqlite3_step(s.cStmt)
 				if rc != SQLITE_ROW {
+					if rc != SQLITE_DONE {
+						wrk.Err = d.db.stepErr(d.ctx, rc, query)
+					}
 					break
 				}
 
-				hitTest = true
 				m := make(map[string]any, 1)
 
 				for i := 0; i < len(wrk.Columns); i++ {
-					m[wrk.Columns[i].Name] = d.db.getStmtColVal(&s, i)
+					m[wrk.Columns[i].Name] = d.db.getStmtColVal(s, i)
 
-					// If the DataType is empty, the column-name
-					// must be an alias (e.g. _rowid_ as RowID);
+					// If the DataType is empty, the column
+					// must be an expression (e.g. count(*));
 					// get the data-type from the value.
-					// Reset the date-type of the column:
-					if i == 0 {
+					if wrk.Columns[i].DataType == "" && m[wrk.Columns[i].Name] != nil {
 						wrk.Columns[i].DataType = GetSQLiteDataType(m[wrk.Columns[i].Name])
 					}
 				}
 				wrk.Rows = append(wrk.Rows, m)
 			}
 
-			if !hitTest {
-				wrk.Columns = nil
-			}
-			C.sqlite3_finalize(s.cStmt)
+			stop()
+
+			d.db.releaseStmt(s)
 		} else {
 			wrk.Err = err
 			// isDBLockedErr = strings.Contains(wrk.Err.Error(), "database is locked")
@@ -510,15 +479,20 @@ func (d *DataTableOp) get(query string, placeHolders ...any) DataTable {
 
 	wrkRes.TimeEnded = time.Now()
 
-	GetDataTableSeqNo--
-
 	return wrkRes
 }
 
 func (d *DB) GetDataTableWithContext(ctx context.Context, query string, placeHolders ...any) (*DataTable, error) {
+	return d.readDataTable(ctx, func(r *DB) (*DataTable, error) {
+		return r.getDataTableWithContext(ctx, query, placeHolders...)
+	})
+}
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+func (d *DB) getDataTableWithContext(ctx context.Context, query string, placeHolders ...any) (*DataTable, error) {
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	var itbl IDataTableOp = &DataTableOp{MaxTries: 3, MillSecToWait: 150, db: d}
 
@@ -541,6 +515,7 @@ type callQueItem struct {
 }
 
 type exeQueue struct {
+	mutex    sync.Mutex
 	ExeQueue []execQueItem
 }
 type execQueItem struct {
@@ -562,8 +537,8 @@ type execQueItem struct {
 }
 
 func (e *exeQueue) Delete(queueID string) {
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 
 	for i := range e.ExeQueue {
 		if e.ExeQueue[i].QueueID == queueID {
@@ -579,12 +554,16 @@ func (e *exeQueue) Add(eqItm execQueItem) {
 		e = new(exeQueue)
 		e.ExeQueue = make([]execQueItem, 0)
 	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	e.ExeQueue = append(e.ExeQueue, eqItm)
 }
 
 func (e *exeQueue) Get(queueID string) execQueItem {
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	for i := range e.ExeQueue {
 		if e.ExeQueue[i].QueueID == queueID {
 			return e.ExeQueue[i]
@@ -931,17 +910,14 @@ func (d *DB) GetDataTable(query string, placeHolders ...any) (*DataTable, error)
 */
 
 func (d *DB) GetDataTable(query string, placeHolders ...any) (*DataTable, error) {
+	return d.readDataTable(context.Background(), func(r *DB) (*DataTable, error) {
+		return r.getDataTable(query, placeHolders...)
+	})
+}
 
-	var wrkRes DataTable
-	var tries int
-
-	// UNDONE:  make these configurable or let the caller
-	// set them via optional args?
-	maxTries := 3
+func (d *DB) getDataTable(query string, placeHolders ...any) (*DataTable, error) {
 
-	// on "database is locked"
-	// time to wait before trying again
-	millSecToWait := 150 // time.Duration(150 * time.Millisecond)
+	var wrkRes DataTable
 
 	// query = strings.TrimSpace(query)
 	// if len(query) < 7 {
@@ -974,69 +950,54 @@ func (d *DB) GetDataTable(query string, placeHolders ...any) (*DataTable, error)
 	// 	}
 	// }
 
-	GetDataTableSeqNo++
+	defer d.requestStarted()()
+	seqNo := d.nextSeqNo()
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
 
 	c := make(chan DataTable)
 	go func() {
 		var wrk DataTable
-		var isDBLockedErr bool
+		var s *Stmt
+		var pzTail string
 		wrk.db = d
-		wrk.SeqNo = GetDataTableSeqNo
+		wrk.SeqNo = int(seqNo)
 
-		tries = 0
 		wrk.TimeStarted = time.Now()
 		query = normalizeSQL(query)
 
-	tryAgain:
-		tries++
-		s, pzTail, err := d.Prepare(query, placeHolders)
+		// retry, if the database is locked
+		err := d.retry(context.Background(), func() error {
+			var err error
+			s, pzTail, err = d.prepareCached(query, placeHolders)
+			return err
+		})
 		if err == nil {
 			wrk.SQLTail = pzTail
 			wrk.Name = getTableNameFromSQLQuery(query)
-			colCnt := int(C.sqlite3_column_count(s.cStmt))
-			for i := range colCnt {
-				// a column name from the query (cStmt) could be
-				// an alias, which would not be in the schema.
-				colName := C.GoString(C.sqlite3_column_name(s.cStmt, C.int(i)))
-				wrk.Columns = append(wrk.Columns, Column{
-					Name: colName, Ordinal: i})
-			}
-
-			colDesc := d.GetTableColumns(wrk.Name)
-
-			// set the columns first; dynamic + IsPrimaryKey
-			for i := range wrk.Columns {
-				for k := range colDesc {
-					if colDesc[k].Name == wrk.Columns[i].Name {
-						wrk.Columns[i] = colDesc[k]
-						break
-					}
-				}
-				// ordinal is relative to the columns
-				// in the query; replace it.
-				wrk.Columns[i].Ordinal = i
-			}
+			// the source table/column of every column; incl.
+			// aliases and the columns of joined tables.
+			wrk.Columns = d.stmtColumns(s)
 			// fetch the rows
-			hitTest := false
 			for {
 				rc := C.sqlite3_step(s.cStmt)
 				if rc != SQLITE_ROW {
+					if rc != SQLITE_DONE {
+						wrk.Err = d.stepErr(context.Background(), rc, query)
+					}
 					break
 				}
 
-				hitTest = true
 				m := make(map[string]any, 1)
 				for i := range wrk.Columns {
-					m[wrk.Columns[i].Name] = d.getStmtColVal(&s, i)
+					m[wrk.Columns[i].Name] = d.getStmtColVal(s, i)
 
-					// If the DataType is empty, the column-name
-					// must be an alias (e.g. _rowid_ as RowID);
+					// If the DataType is empty, the column
+					// must be an expression (e.g. count(*));
 					// get the data-type from the value.
-					// Reset the date-type of the column:
-					if i == 0 {
+					if wrk.Columns[i].DataType == "" && m[wrk.Columns[i].Name] != nil {
 						wrk.Columns[i].DataType = GetSQLiteDataType(m[wrk.Columns[i].Name])
 					}
 				}
@@ -1044,20 +1005,10 @@ func (d *DB) GetDataTable(query string, placeHolders ...any) (*DataTable, error)
 
 			}
 
-			if !hitTest {
-				wrk.Columns = nil
-			}
-
-			C.sqlite3_finalize(s.cStmt)
+			d.releaseStmt(s)
 
 		} else {
 			wrk.Err = err
-			isDBLockedErr = strings.Contains(wrk.Err.Error(), "database is locked")
-
-			if tries <= maxTries && wrk.Err != nil && isDBLockedErr {
-				C.sqlite3_sleep(C.int(millSecToWait))
-				goto tryAgain
-			}
 		}
 		c <- wrk
 	}()
@@ -1066,8 +1017,6 @@ func (d *DB) GetDataTable(query string, placeHolders ...any) (*DataTable, error)
 
 	wrkRes.TimeEnded = time.Now()
 
-	GetDataTableSeqNo--
-
 	return &wrkRes, wrkRes.Err
 }
 
@@ -73,7 +73,6 @@ func CreateDatabase(dbFilePath string, noBuiltInTables ...bool) error {
 
 // OpenV2Exclusive opens a database exclusively.
 func OpenV2Exclusive(dbFilePath string, pragma ...string) (*DB, error) {
-	pragma = applyDefaultPragma(pragma...)
 	dhwnd, err := openV2(dbFilePath,
 		C.SQLITE_OPEN_EXCLUSIVE|
 			C.SQLITE_OPEN_READWRITE|
@@ -149,7 +148,6 @@ func OpenMemory(vfsName ...string) (*DB, error) {
 	vfsNamePtr := C.CString(vfsNamex)
 	defer C.free(unsafe.Pointer(vfsNamePtr))
 
-	mCMutex.Lock()
 	res := C.sqlite3_open_v2(fMem, &d.DBHwnd,
 		C.SQLITE_OPEN_MEMORY|
 			C.SQLITE_OPEN_READWRITE|
@@ -158,16 +156,15 @@ func OpenMemory(vfsName ...string) (*DB, error) {
 		vfsNamePtr)
 
 	err := getSQLiteErr(res, d.DBHwnd)
-	mCMutex.Unlock()
 
 	if err == nil {
 		d.Closed = false
 
 	} else {
-		DBGrp.Add(&d)
+		DBGrp.Add(d)
 	}
 
-	return &d, err
+	return d, err
 }
 
 // DBCacheFlush Flush caches to disk mid-transaction.
@@ -210,15 +207,14 @@ func Open(dbFilePath string, pragma ...string) (*DB, error) {
 	// init the db instance
 	d := initDB(dbFilePath)
 
-	mCMutex.Lock()
 	res := C.sqlite3_open(fPath, &d.DBHwnd)
 	err := getSQLiteErr(res, d.DBHwnd)
-	mCMutex.Unlock()
 
 	d.Name = strings.TrimSuffix(path.Base(dbFilePath), ".sqlite")
 
 	if err == nil {
 		d.Closed = false
+		d.connected()
 	}
 	for i := 0; i < len(pragma); i++ {
 		_, err := d.Execute(pragma[i])
@@ -227,13 +223,9 @@ func Open(dbFilePath string, pragma ...string) (*DB, error) {
 		}
 	}
 
-	// refrence the db bak to its InMemory object,
-	// otherwise its DBHwnd will be null
-	d.InMemory.db = &d
+	DBGrp.Add(d)
 
-	DBGrp.Add(&d)
-
-	return &d, err
+	return d, err
 }
 
 func GetVersion() SQLiteVersion {
@@ -296,7 +288,6 @@ func EncryptDBFile(dbPath string, encFilePath string, pwdPhrs string) error {
 // https://www.sqlite.org/c3ref/open.html#urifilenameexamples
 // https://www.sqlite.org/vfs.html
 func openV2(dbFilePath string, flag C.int, vfsName string, pragma []string) (*DB, error) {
-	pragma = applyDefaultPragma(pragma...)
 
 	if dbFilePath == "" || !fileOrDirExists(dbFilePath) {
 		return nil, errors.New("database file does not exist")
@@ -317,13 +308,13 @@ func openV2(dbFilePath string, flag C.int, vfsName string, pragma []string) (*DB
 	vfsNamePtr := C.CString(vfsName)
 	defer C.free(unsafe.Pointer(vfsNamePtr))
 
-	mCMutex.Lock()
 	res := C.sqlite3_open_v2(fPath, &d.DBHwnd, flag, vfsNamePtr)
 	err := getSQLiteErr(res, d.DBHwnd)
-	mCMutex.Unlock()
 
 	if err == nil {
 		d.Closed = false
+		d.vfsName = vfsName
+		d.connected()
 	}
 
 	// see: https://sqlite.org/pragma.html#pragma_journal_mode
@@ -334,9 +325,9 @@ func openV2(dbFilePath string, flag C.int, vfsName string, pragma []string) (*DB
 		}
 	}
 
-	DBGrp.Add(&d)
+	DBGrp.Add(d)
 
-	return &d, err
+	return d, err
 }
 
 func ExecuteNonQueryFromFile(dbFilePath, query string, placeHolders ...any) (int64, error) {
@@ -352,9 +343,9 @@ func ExecuteNonQueryFromFile(dbFilePath, query string, placeHolders ...any) (int
 // GetOpenedDB returns a pointer to daabasee
 // that is already open.
 func GetOpenedDB(fp string) *DB {
-	for i := 0; i < len(DBGrp.Base().OpenDatabases); i++ {
-		if DBGrp.Base().OpenDatabases[i].FilePath() == fp {
-			return DBGrp.Base().OpenDatabases[i]
+	for _, db := range DBGrp.Base().list() {
+		if db.FilePath() == fp {
+			return db
 		}
 	}
 
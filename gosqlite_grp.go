@@ -13,53 +13,49 @@ import (
 	"errors"
 	"log"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 )
 
 func (g *DBGroup) bgProc() {
 	// Ping the databases. Remove the ones that
 	// do not respond.
 	for {
-		for i := range len(g.OpenDatabases) {
-			err := g.Ping(g.OpenDatabases[i])
+		for _, d := range g.list() {
+			err := g.Ping(d)
 			msgTxt := "ok"
 			if err != nil {
 				msgTxt = err.Error()
 			}
 			if g.Verbose {
-				log.Println("ping:", g.OpenDatabases[i].Name, msgTxt)
+				log.Println("ping:", d.Name, msgTxt)
 			}
 			if err != nil {
 				// remove from the list
-				newArr := make([]*DB, 0)
-				for j := 0; j < len(g.OpenDatabases); j++ {
-					if g.OpenDatabases[j].UniqueName != g.OpenDatabases[i].UniqueName {
-						newArr = append(newArr, g.OpenDatabases[j])
-					}
-				}
-				g.OpenDatabases = newArr
+				g.drop(d)
 
 				break
 
 			} else {
-				db, err := g.Get(g.OpenDatabases[i].FilePath())
+				db, err := g.Get(d.FilePath())
 				if err != nil {
 					if g.Verbose {
-						log.Println(g.OpenDatabases[i].Name, "=>", err)
+						log.Println(d.Name, "=>", err)
 					}
 				} else {
 					// optimize
 					_, err := db[0].Execute("PRAGMA optimize;")
 					if err != nil {
 						if g.Verbose {
-							log.Println(g.OpenDatabases[i].Name, "=>", err)
+							log.Println(d.Name, "=>", err)
 						}
 					}
 					// vacuum the db
 					m, err := db[0].ExecuteScalare("PRAGMA freelist_count;")
 					if err != nil {
 						if g.Verbose {
-							log.Println(g.OpenDatabases[i].Name, "=>", err)
+							log.Println(d.Name, "=>", err)
 						}
 					} else {
 						var freeCnt int64
@@ -74,15 +70,15 @@ func (g *DBGroup) bgProc() {
 							// PRAGMA page_size;
 							// PRAGMA freelist_count;
 							// select (<page_size>.0 * <freelist_count>.0) / 1024.0 / 1024.0
-							if freeCnt > 50 && !g.OpenDatabases[i].Busy() {
+							if freeCnt > 50 && !d.Busy() {
 								if g.Verbose {
-									log.Println("<<< VACUUM >>>", g.OpenDatabases[i].Name)
+									log.Println("<<< VACUUM >>>", d.Name)
 								}
-								err = g.OpenDatabases[i].Vacuum()
+								err = d.Vacuum()
 								if err != nil && g.Verbose {
 									log.Println(err)
 								} else {
-									g.OpenDatabases[i].ShrinkMemory()
+									d.ShrinkMemory()
 								}
 							}
 						}
@@ -102,25 +98,50 @@ func (m *DBGroup) Base() *DBGroup {
 	return m
 }
 
+var grouperOnce sync.Once
+
+// initGrouper starts the bgProc() of the group;
+// once for all databases.
 func initGrouper() {
+	grouperOnce.Do(func() {
+		go DBGrp.bgProc()
+	})
+}
 
-	if len(DBGrp.Base().OpenDatabases) > 0 {
-		return
-	}
+// list is a copy of OpenDatabases; so that it can be
+// iterated while databases are added or removed.
+func (m *DBGroup) list() []*DB {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return slices.Clone(m.OpenDatabases)
+}
 
-	DBGrp = &DBGroup{}
+// drop removes db from OpenDatabases; it does
+// not close the database.
+func (m *DBGroup) drop(db *DB) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	go DBGrp.bgProc()
+	n := len(m.OpenDatabases)
+	m.OpenDatabases = slices.DeleteFunc(m.OpenDatabases, func(x *DB) bool {
+		return x.UniqueName == db.UniqueName
+	})
+
+	return len(m.OpenDatabases) < n
 }
 
 func (m *DBGroup) Count() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	return len(m.OpenDatabases)
 }
 
 func (m *DBGroup) Find(dbFilePath string) *DB {
-	for i := range m.Count() {
-		if strings.EqualFold(m.OpenDatabases[i].FilePath(), dbFilePath) {
-			return m.OpenDatabases[i]
+	for _, db := range m.list() {
+		if strings.EqualFold(db.FilePath(), dbFilePath) {
+			return db
 		}
 	}
 
@@ -128,47 +149,7 @@ func (m *DBGroup) Find(dbFilePath string) *DB {
 }
 
 func (m *DBGroup) Exists(dbFilePath string) (exists bool) {
-	for i := range m.Count() {
-		if strings.EqualFold(m.OpenDatabases[i].FilePath(), dbFilePath) {
-			return true
-		}
-	}
-
-	return
-}
-
-func applyDefaultPragma(pragma ...string) []string {
-
-	if len(pragma) == 0 {
-		pragma = append(pragma, defaultPRAGMAJournalMode)
-	}
-	found := false
-	for i := range pragma {
-		s := removeDoubleSpace(pragma[i])
-		if strings.EqualFold(s, "pragma main.secure_delete = on") {
-			found = true
-			break
-		}
-	}
-	if !found {
-		pragma = append(pragma, "PRAGMA main.secure_delete = ON")
-	}
-
-	// https://sqlite.org/pragma.html#pragma_stats
-	// PRAGMA schema.synchronous = 0 | OFF | 1 | NORMAL | 2 | FULL | 3 | EXTRA;
-	found = false
-	for i := range pragma {
-		s := removeDoubleSpace(pragma[i])
-		if strings.EqualFold(s, "pragma main.synchronous") {
-			found = true
-			break
-		}
-	}
-	if !found {
-		pragma = append(pragma, "PRAGMA main.synchronous = OFF")
-	}
-
-	return pragma
+	return m.Find(dbFilePath) != nil
 }
 
 func (m *DBGroup) Get(srchTxt string, pragma ...string) ([]*DB, error) {
@@ -177,17 +158,17 @@ func (m *DBGroup) Get(srchTxt string, pragma ...string) ([]*DB, error) {
 
 	sLower := strings.ToLower(srchTxt)
 
-	for i := range m.Count() {
-		if m.OpenDatabases[i].UniqueName == srchTxt {
-			arryDB = append(arryDB, m.OpenDatabases[i])
+	for _, db := range m.list() {
+		if db.UniqueName == srchTxt {
+			arryDB = append(arryDB, db)
 			continue
 
-		} else if strings.EqualFold(m.OpenDatabases[i].FilePath(), sLower) {
-			arryDB = append(arryDB, m.OpenDatabases[i])
+		} else if strings.EqualFold(db.FilePath(), sLower) {
+			arryDB = append(arryDB, db)
 			continue
 
-		} else if strings.Contains(strings.ToLower(m.OpenDatabases[i].FilePath()), sLower) {
-			arryDB = append(arryDB, m.OpenDatabases[i])
+		} else if strings.Contains(strings.ToLower(db.FilePath()), sLower) {
+			arryDB = append(arryDB, db)
 		}
 	}
 
@@ -195,7 +176,6 @@ func (m *DBGroup) Get(srchTxt string, pragma ...string) ([]*DB, error) {
 		// try to open the database, if the file exists on disk.
 		fp := srchTxt
 		if fileOrDirExists(srchTxt) {
-			pragma = applyDefaultPragma(pragma...)
 			db, err := Open(fp, pragma...)
 			if err != nil {
 				/* empty array */
@@ -231,6 +211,10 @@ func (g *DBGroup) Add(db *DB) {
 	if g == nil || db == nil {
 		return
 	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
 	for i := 0; i < len(g.OpenDatabases); i++ {
 		if g.OpenDatabases[i].UniqueName == db.UniqueName {
 			return
@@ -257,37 +241,11 @@ func (g *DBGroup) Remove(db *DB) {
 		return
 	}
 
-	mCMutex.Lock()
-	defer mCMutex.Unlock()
-
-	c := make(chan int)
-	go func() {
-		indx := -1
-		for i := 0; i < len(g.OpenDatabases); i++ {
-			if g.OpenDatabases[i].UniqueName == db.UniqueName {
-				indx = i
-				break
-			}
-		}
-		if indx < 0 {
-			return
-		}
-		err := db.Close()
-		if err != nil {
-			// bad parameter or other API misuse: means the database is already closed.
-			if !strings.Contains(err.Error(), "bad parameter or other API misuse") &&
-				!strings.Contains(err.Error(), "not an error") {
-				// UNDONE
-			}
-		}
-		db = nil
-
-		// remove from array of OpenDatabases
-		if indx < len(g.OpenDatabases) {
-			g.OpenDatabases[len(g.OpenDatabases)-1], g.OpenDatabases[indx] = g.OpenDatabases[indx], g.OpenDatabases[len(g.OpenDatabases)-1]
-			g.OpenDatabases = g.OpenDatabases[:len(g.OpenDatabases)-1]
-		}
-	}()
+	if !g.drop(db) {
+		return
+	}
 
-	close(c)
+	// an error (bad parameter or other API misuse) means
+	// the database is already closed; i.e. nothing to do.
+	db.Close()
 }
@@ -0,0 +1,52 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens a new database in a temp dir of the test, in WAL
+// mode if wal is set; it is closed when the test ends.
+func openTestDB(t *testing.T, wal bool) *DB {
+	t.Helper()
+
+	opts := []Option{WithCreate()}
+	if wal {
+		opts = append(opts, WithJournalMode(JounalMode().Wal))
+	}
+
+	db, err := OpenWith(filepath.Join(t.TempDir(), "test.db"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// mustExec runs sql statement(s) and fails the test on error.
+func mustExec(t *testing.T, db *DB, query string, args ...any) {
+	t.Helper()
+
+	res := db.Exec(query, args...)
+	if err := res.Error(); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// count returns the result of a select count(*) query.
+func count(t *testing.T, db *DB, query string, args ...any) int64 {
+	t.Helper()
+
+	n, err := QueryOne[int64](db, query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	return n
+}
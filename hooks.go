@@ -0,0 +1,508 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include "sqlite3.h"
+// void go_sqlite3_update_hook(uintptr_t h, int op, char *db, char *table, sqlite3_int64 rowid);
+// int go_sqlite3_commit_hook(uintptr_t h);
+// void go_sqlite3_rollback_hook(uintptr_t h);
+// static void update_hook(void *h, int op, const char *db, const char *table, sqlite3_int64 rowid){
+//   go_sqlite3_update_hook((uintptr_t)h, op, (char*)db, (char*)table, rowid);
+// }
+// static int commit_hook(void *h){
+//   return go_sqlite3_commit_hook((uintptr_t)h);
+// }
+// static void rollback_hook(void *h){
+//   go_sqlite3_rollback_hook((uintptr_t)h);
+// }
+// static void set_hooks(sqlite3 *db, uintptr_t h){
+//   sqlite3_update_hook(db, update_hook, (void*)h);
+//   sqlite3_commit_hook(db, commit_hook, (void*)h);
+//   sqlite3_rollback_hook(db, rollback_hook, (void*)h);
+// }
+// static void clear_hooks(sqlite3 *db){
+//   sqlite3_update_hook(db, NULL, NULL);
+//   sqlite3_commit_hook(db, NULL, NULL);
+//   sqlite3_rollback_hook(db, NULL, NULL);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"runtime/cgo"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Op is the operation of a change to a row.
+type Op int
+
+const (
+	OpInsert Op = C.SQLITE_INSERT
+	OpUpdate Op = C.SQLITE_UPDATE
+	OpDelete Op = C.SQLITE_DELETE
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpInsert:
+		return "INSERT"
+	case OpUpdate:
+		return "UPDATE"
+	case OpDelete:
+		return "DELETE"
+	}
+
+	return fmt.Sprintf("Op(%d)", int(o))
+}
+
+// Change is a change to a row of a table.
+type Change struct {
+	Op    Op
+	DB    string // e.g. main, temp or an attached database
+	Table string
+	Rowid int64
+}
+
+// ChangeEvent is the changes of a committed transaction
+// (to the tables of a subscription); see DB.Subscribe().
+type ChangeEvent struct {
+	Changes []Change
+}
+
+// Tables returns the names of the changed tables.
+func (e ChangeEvent) Tables() []string {
+	var tables []string
+	for _, c := range e.Changes {
+		if !slices.Contains(tables, c.Table) {
+			tables = append(tables, c.Table)
+		}
+	}
+
+	return tables
+}
+
+// hookState holds the hooks of a DB; the hooks are set on the
+// connection while there is a func or a subscription.
+type hookState struct {
+	mu         sync.Mutex
+	handle     cgo.Handle // of the hookState; 0 if the hooks are not set
+	hwnd       *C.sqlite3 // the connection the hooks are set on
+	onUpdate   func(op Op, dbName, table string, rowid int64)
+	onCommit   func() bool
+	onRollback func()
+	subs       []*subscription
+
+	// pending are the changes of the current
+	// transaction, if there are subscriptions.
+	pending []Change
+
+	// committed are the changes of a transaction that is being
+	// committed (the commit hook has returned); they are moved to
+	// done by seal(), once the commit is done. done are sent to
+	// the subscriptions by flush(); one event per transaction.
+	committed    []Change
+	done         [][]Change
+	hasCommitted atomic.Bool // committed or done are not empty
+}
+
+func newHookState() *hookState {
+	return &hookState{}
+}
+
+// OnUpdate sets a func that is called when a row is inserted, updated
+// or deleted (in a rowid table); nil removes it. It is called before
+// the transaction commits (i.e. the change may still be rolled back),
+// and fn must not use the database. A panic of fn is recovered and
+// ignored (it cannot be returned to sqlite3). Rows deleted by the
+// truncate optimization (DELETE without WHERE) or by ON CONFLICT
+// REPLACE are not reported by sqlite3.
+// See: https://www.sqlite.org/c3ref/update_hook.html
+func (d *DB) OnUpdate(fn func(op Op, dbName, table string, rowid int64)) error {
+	return d.setHooks(func(s *hookState) { s.onUpdate = fn })
+}
+
+// OnCommit sets a func that is called when a transaction is about
+// to commit; it returns false to roll it back instead (the commit
+// fails with SQLITE_CONSTRAINT_COMMITHOOK). If fn panics, the panic
+// is recovered and the transaction is rolled back, as if fn returned
+// false. nil removes it. fn must not use the database.
+// See: https://www.sqlite.org/c3ref/commit_hook.html
+func (d *DB) OnCommit(fn func() bool) error {
+	return d.setHooks(func(s *hookState) { s.onCommit = fn })
+}
+
+// OnRollback sets a func that is called when a transaction is
+// rolled back; nil removes it. fn must not use the database; a
+// panic of fn is recovered and ignored.
+func (d *DB) OnRollback(fn func()) error {
+	return d.setHooks(func(s *hookState) { s.onRollback = fn })
+}
+
+// Subscribe returns a channel of the changes to the tables (or to all
+// tables, if none), with one ChangeEvent per committed transaction;
+// e.g. to invalidate a cache:
+//
+//	ch, err := db.Subscribe("person", "address")
+//	...
+//	go func() {
+//		for e := range ch {
+//			cache.Invalidate(e.Tables()...)
+//		}
+//	}()
+//
+// An event is sent after the COMMIT has succeeded (not by the commit
+// hook), and not at all for a transaction that is rolled back. The
+// events are queued, so that a slow receiver does not block the
+// writes; the channel is closed by Unsubscribe() or Close().
+// The changes are as by OnUpdate(); i.e. the changes that are
+// undone by a failed statement or a ROLLBACK TO within a committed
+// transaction are reported as well.
+func (d *DB) Subscribe(tables ...string) (<-chan ChangeEvent, error) {
+	sub := newSubscription(tables)
+
+	err := d.setHooks(func(s *hookState) { s.subs = append(s.subs, sub) })
+	if err != nil {
+		return nil, err
+	}
+
+	go sub.run()
+
+	return sub.ch, nil
+}
+
+// Unsubscribe ends a subscription of Subscribe(); its
+// channel is closed, and the queued events are dropped.
+func (d *DB) Unsubscribe(ch <-chan ChangeEvent) error {
+	found := false
+
+	err := d.setHooks(func(s *hookState) {
+		s.subs = slices.DeleteFunc(s.subs, func(sub *subscription) bool {
+			if sub.ch != ch {
+				return false
+			}
+			sub.close()
+			found = true
+			return true
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("subscription not found")
+	}
+
+	return nil
+}
+
+// setHooks changes the hooks of the DB, and sets (or clears)
+// them on the connection.
+func (d *DB) setHooks(change func(s *hookState)) error {
+	if d == nil || d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+
+	// the hooks are called with the lock of the connection
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	s := d.hooks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change(s)
+
+	used := s.onUpdate != nil || s.onCommit != nil || s.onRollback != nil || len(s.subs) > 0
+
+	switch {
+	case used && s.handle == 0:
+		s.handle = cgo.NewHandle(s)
+		s.hwnd = d.DBHwnd
+		C.set_hooks(d.DBHwnd, C.uintptr_t(s.handle))
+
+	case !used && s.handle != 0:
+		C.clear_hooks(d.DBHwnd)
+		s.handle.Delete()
+		s.handle = 0
+		s.hwnd = nil
+	}
+	if len(s.subs) == 0 {
+		s.pending = nil
+		s.committed = nil
+		s.done = nil
+		s.hasCommitted.Store(false)
+	}
+
+	return nil
+}
+
+// close ends the subscriptions and deletes the handle; the
+// connection is closed.
+func (s *hookState) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		sub.close()
+	}
+	s.subs = nil
+	s.pending = nil
+	s.committed = nil
+	s.done = nil
+	s.hasCommitted.Store(false)
+
+	if s.handle != 0 {
+		s.handle.Delete()
+		s.handle = 0
+	}
+	s.hwnd = nil
+}
+
+// update is the update hook.
+func (s *hookState) update(c Change) {
+	s.mu.Lock()
+	fn := s.onUpdate
+	if len(s.subs) > 0 {
+		s.pending = append(s.pending, c)
+	}
+	s.mu.Unlock()
+
+	if fn != nil {
+		callHook(func() { fn(c.Op, c.DB, c.Table, c.Rowid) })
+	}
+}
+
+// commit is the commit hook; it returns false to roll back the
+// transaction. The commit can still fail after it (e.g. on
+// SQLITE_BUSY or an I/O error); so the pending changes are kept
+// as committed, until seal() finds the commit done.
+func (s *hookState) commit() bool {
+	s.mu.Lock()
+	fn := s.onCommit
+	s.mu.Unlock()
+
+	if fn != nil {
+		ok := false
+		if panicked := callHook(func() { ok = fn() }); panicked || !ok {
+			// a panic vetoes the commit as well; the
+			// rollback hook drops the pending changes.
+			return false
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) > 0 {
+		s.committed = append(s.committed, s.pending...)
+		s.pending = nil
+		s.hasCommitted.Store(true)
+	}
+
+	return true
+}
+
+// rollback is the rollback hook; the changes of a commit that
+// failed after the commit hook are dropped as well (seal() runs
+// after every statement, so they are not of an earlier commit).
+func (s *hookState) rollback() {
+	s.mu.Lock()
+	fn := s.onRollback
+	s.pending = nil
+	s.committed = nil
+	s.hasCommitted.Store(len(s.done) > 0)
+	s.mu.Unlock()
+
+	if fn != nil {
+		callHook(fn)
+	}
+}
+
+// seal keeps the committed changes as done, if the commit is done;
+// i.e. the connection is out of the transaction. It is called after
+// every statement of the writer, with the lock of the connection held.
+func (s *hookState) seal() {
+	if s == nil || !s.hasCommitted.Load() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sealLocked()
+}
+
+// sealLocked is seal(); the caller holds mu.
+func (s *hookState) sealLocked() {
+	if len(s.committed) == 0 || s.hwnd == nil || C.sqlite3_get_autocommit(s.hwnd) == 0 {
+		// e.g. the COMMIT failed with SQLITE_BUSY; the
+		// transaction is still open and can be committed.
+		return
+	}
+
+	s.done = append(s.done, s.committed)
+	s.committed = nil
+}
+
+// flush sends the changes of the done commits to the subscriptions;
+// it is called when the lock of the writer connection is released
+// (see connMutex); i.e. once per script or statement.
+func (s *hookState) flush() {
+	if s == nil || !s.hasCommitted.Load() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sealLocked()
+
+	for _, changes := range s.done {
+		for _, sub := range s.subs {
+			sub.send(changes)
+		}
+	}
+	s.done = nil
+	s.hasCommitted.Store(len(s.committed) > 0)
+}
+
+// callHook calls a func of a hook; a panic is recovered, since
+// it can not be returned to sqlite3. It reports whether fn panicked.
+func callHook(fn func()) (panicked bool) {
+	defer func() {
+		if recover() != nil {
+			panicked = true
+		}
+	}()
+
+	fn()
+
+	return false
+}
+
+// connMutex is the lock of a connection; see DB.connLock(). For the
+// writer, hooks is set: unlocking it (i.e. after a statement or a
+// script) sends the changes of the committed transactions to the
+// subscriptions.
+type connMutex struct {
+	sync.Mutex
+	hooks *hookState
+}
+
+func (m *connMutex) Unlock() {
+	m.hooks.flush()
+	m.Mutex.Unlock()
+}
+
+// subscription is a subscription of Subscribe(); the events are
+// queued by send(), and sent to ch by run().
+type subscription struct {
+	tables []string // lower case; all, if empty
+	ch     chan ChangeEvent
+
+	mu     sync.Mutex
+	queue  []ChangeEvent
+	notify chan struct{}
+	done   chan struct{}
+	closed bool
+}
+
+func newSubscription(tables []string) *subscription {
+	sub := subscription{
+		ch:     make(chan ChangeEvent),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	for _, t := range tables {
+		sub.tables = append(sub.tables, strings.ToLower(t))
+	}
+
+	return &sub
+}
+
+// wants reports whether a change is to a table of the
+// subscription; a table can be qualified, e.g. main.person.
+func (sub *subscription) wants(c Change) bool {
+	if len(sub.tables) == 0 {
+		return true
+	}
+
+	table := strings.ToLower(c.Table)
+	qualified := strings.ToLower(c.DB) + "." + table
+
+	return slices.Contains(sub.tables, table) || slices.Contains(sub.tables, qualified)
+}
+
+// send queues the changes of a transaction (to the tables
+// of the subscription, if any).
+func (sub *subscription) send(changes []Change) {
+	var e ChangeEvent
+	for _, c := range changes {
+		if sub.wants(c) {
+			e.Changes = append(e.Changes, c)
+		}
+	}
+	if len(e.Changes) == 0 {
+		return
+	}
+
+	sub.mu.Lock()
+	if !sub.closed {
+		sub.queue = append(sub.queue, e)
+	}
+	sub.mu.Unlock()
+
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the queued events to ch, until the
+// subscription is closed; then ch is closed.
+func (sub *subscription) run() {
+	defer close(sub.ch)
+
+	for {
+		sub.mu.Lock()
+		if len(sub.queue) == 0 {
+			sub.mu.Unlock()
+
+			select {
+			case <-sub.notify:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+		e := sub.queue[0]
+		sub.queue[0] = ChangeEvent{}
+		sub.queue = sub.queue[1:]
+		sub.mu.Unlock()
+
+		select {
+		case sub.ch <- e:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (sub *subscription) close() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if !sub.closed {
+		sub.closed = true
+		sub.queue = nil
+		close(sub.done)
+	}
+}
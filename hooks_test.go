@@ -0,0 +1,142 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// nextEvent returns the next event of a subscription; ok is
+// false if there is none within a short time.
+func nextEvent(ch <-chan ChangeEvent) (e ChangeEvent, ok bool) {
+	select {
+	case e, ok = <-ch:
+		return e, ok
+	case <-time.After(200 * time.Millisecond):
+		return e, false
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	mustExec(t, db, "create table u(a)")
+
+	ch, err := db.Subscribe("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustExec(t, db, "insert into u values(1)")
+	mustExec(t, db, "insert into t values(1)")
+
+	e, ok := nextEvent(ch)
+	if !ok {
+		t.Fatal("no event")
+	}
+	want := []Change{{Op: OpInsert, DB: "main", Table: "t", Rowid: 1}}
+	if !slices.Equal(e.Changes, want) {
+		t.Fatalf("got %v, want %v", e.Changes, want)
+	}
+
+	// no event until the commit; one for the transaction
+	tx, err := db.BeginTx(context.Background(), TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("update t set a = 2")
+	tx.Exec("delete from t where a = 2")
+	if e, ok := nextEvent(ch); ok {
+		t.Fatalf("event before the commit: %v", e)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	e, ok = nextEvent(ch)
+	if !ok || len(e.Changes) != 2 || e.Changes[0].Op != OpUpdate || e.Changes[1].Op != OpDelete {
+		t.Fatalf("got %v, %v", e, ok)
+	}
+
+	// none for a rollback
+	tx, err = db.BeginTx(context.Background(), TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("insert into t values(3)")
+	tx.Rollback()
+	if e, ok := nextEvent(ch); ok {
+		t.Fatalf("event of a rollback: %v", e)
+	}
+
+	if err := db.Unsubscribe(ch); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("the channel is not closed")
+	}
+}
+
+func TestOnCommitVeto(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	ch, err := db.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rollbacks := 0
+	db.OnRollback(func() { rollbacks++ })
+
+	for _, fn := range []func() bool{
+		func() bool { return false },
+		func() bool { panic("fail") },
+	} {
+		db.OnCommit(fn)
+
+		res := db.Exec("insert into t values(1)")
+		if !errors.Is(res.Error(), ErrConstraint) {
+			t.Fatalf("got %v, want a constraint error", res.Error())
+		}
+		if e, ok := nextEvent(ch); ok {
+			t.Fatalf("event of a vetoed commit: %v", e)
+		}
+	}
+
+	db.OnCommit(nil)
+	if n := count(t, db, "select count(*) from t"); n != 0 {
+		t.Fatalf("got %d rows, want 0", n)
+	}
+	if rollbacks != 2 {
+		t.Fatalf("got %d rollbacks, want 2", rollbacks)
+	}
+}
+
+func TestOnUpdate(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	var ops []Op
+	db.OnUpdate(func(op Op, dbName, table string, rowid int64) {
+		ops = append(ops, op)
+	})
+
+	mustExec(t, db, "insert into t values(1)")
+	mustExec(t, db, "update t set a = 2")
+	mustExec(t, db, "delete from t where a = 2")
+
+	db.OnUpdate(nil)
+	mustExec(t, db, "insert into t values(1)")
+
+	want := []Op{OpInsert, OpUpdate, OpDelete}
+	if !slices.Equal(ops, want) {
+		t.Fatalf("got %v, want %v", ops, want)
+	}
+}
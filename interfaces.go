@@ -5,7 +5,10 @@
 
 package gosqlite
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 // IDB is an instance for a single database.
 type IDB interface {
@@ -361,6 +364,10 @@ type Result struct {
 	err          error
 	// PageCount    int64
 
+	// the statement and its line in a script; see ExecScript()
+	sql  string
+	line int
+
 	intfce  IResult // this ensures IResult is implemented
 	NotUsed string  // this is so that there is one exported field
 }
@@ -384,6 +391,9 @@ type IResult interface {
 type DBGroup struct {
 	Verbose       bool
 	OpenDatabases []*DB
+
+	// mutex guards OpenDatabases.
+	mutex sync.Mutex
 }
 
 type IDBGroup interface {
@@ -0,0 +1,379 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMigrationTable is the history table of a Migrator,
+// if not set.
+const DefaultMigrationTable = "schema_migrations"
+
+// Migration is a version of the schema; i.e. the SQL of a
+// NNNN_name.up.sql file and of its (optional) NNNN_name.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+
+	// Up and Down are the SQL scripts; see ExecScript().
+	Up   string
+	Down string
+}
+
+// MigrationStatus is the state of a migration in the database;
+// see Migrator.Status().
+type MigrationStatus struct {
+	Migration
+
+	Applied bool
+
+	// AppliedAt is zero, if the migration is not in the
+	// history table.
+	AppliedAt time.Time
+}
+
+// Migrator applies versioned migrations to a database. The version
+// of the schema is PRAGMA user_version; the applied migrations are
+// also recorded in a history table (see DefaultMigrationTable).
+// Up() and Down() hold an exclusive transaction for the whole run,
+// so two processes cannot migrate the same database at the same time;
+// every migration runs in its own savepoint. Statements that cannot
+// run in a transaction (e.g. VACUUM, PRAGMA foreign_keys) cannot be
+// a part of a migration.
+type Migrator struct {
+	// Table is the history table; default: DefaultMigrationTable.
+	Table string
+
+	// DryRun reports the migrations that Up() and Down()
+	// would run, without running them.
+	DryRun bool
+
+	db         *DB
+	migrations []Migration
+}
+
+// migrationFile is the name of a migration file;
+// e.g. 0001_create_person.up.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// NewMigrator reads the migrations in dir of fsys (e.g. an embed.FS);
+// the files are named NNNN_name.up.sql and NNNN_name.down.sql, where
+// NNNN is the version (> 0). Other files are ignored. e.g.
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//	...
+//	m, err := gosqlite.NewMigrator(db, migrations, "migrations")
+//	if err != nil {
+//		return err
+//	}
+//	applied, err := m.Up(ctx)
+func NewMigrator(db *DB, fsys fs.FS, dir string) (*Migrator, error) {
+	if db == nil {
+		return nil, errors.New("database is nil")
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+
+		v := migrationFile.FindStringSubmatch(e.Name())
+		if v == nil {
+			return nil, fmt.Errorf("%s: invalid migration file name; NNNN_name.up.sql or NNNN_name.down.sql expected", e.Name())
+		}
+		version, err := strconv.Atoi(v[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s: invalid version", e.Name())
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: v[2]}
+			byVersion[version] = m
+		}
+		if m.Name != v[2] {
+			return nil, fmt.Errorf("version %d is used by %s and %s", version, m.Name, v[2])
+		}
+
+		if v[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	mg := Migrator{Table: DefaultMigrationTable, db: db}
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("version %d (%s) has no up migration", m.Version, m.Name)
+		}
+		mg.migrations = append(mg.migrations, *m)
+	}
+	sort.Slice(mg.migrations, func(i, j int) bool {
+		return mg.migrations[i].Version < mg.migrations[j].Version
+	})
+
+	return &mg, nil
+}
+
+// Migrations returns the migrations, ordered by version.
+func (mg *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), mg.migrations...)
+}
+
+// Up applies the pending migrations, in order; it returns the applied
+// ones (or the ones that would be applied, in a dry-run). If a
+// migration fails, it is rolled back, the ones before it are kept, and
+// the error names the migration.
+func (mg *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := mg.run(ctx, func(tx *Tx, version int) error {
+		for _, m := range mg.migrations {
+			if m.Version <= version {
+				continue
+			}
+			if mg.DryRun {
+				applied = append(applied, m)
+				continue
+			}
+
+			err := mg.apply(tx, m, m.Up, m.Version, func() error {
+				res := tx.Exec(fmt.Sprintf(`INSERT OR REPLACE INTO %s(version, name, applied_at) VALUES(?, ?, ?)`,
+					quoteIdent(mg.Table)), m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+				return res.Error()
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last n applied migrations, newest first; it returns
+// the reverted ones (or the ones that would be reverted, in a dry-run).
+func (mg *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n < 0 {
+		return nil, errors.New("n cannot be negative")
+	}
+
+	var reverted []Migration
+
+	err := mg.run(ctx, func(tx *Tx, version int) error {
+		for i := len(mg.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			m := mg.migrations[i]
+			if m.Version > version {
+				continue
+			}
+			if strings.TrimSpace(m.Down) == "" {
+				return fmt.Errorf("migration %d (%s) has no down migration", m.Version, m.Name)
+			}
+			if mg.DryRun {
+				reverted = append(reverted, m)
+				continue
+			}
+
+			prev := 0
+			if i > 0 {
+				prev = mg.migrations[i-1].Version
+			}
+			err := mg.apply(tx, m, m.Down, prev, func() error {
+				res := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE version = ?`, quoteIdent(mg.Table)), m.Version)
+				return res.Error()
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status returns the migrations and whether they are applied;
+// i.e. their version is up to the user_version of the database.
+func (mg *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var status []MigrationStatus
+
+	err := mg.db.WithTx(ctx, func(tx *Tx) error {
+		version, err := mg.version(tx)
+		if err != nil {
+			return err
+		}
+
+		appliedAt, err := mg.history(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range mg.migrations {
+			status = append(status, MigrationStatus{
+				Migration: m,
+				Applied:   m.Version <= version,
+				AppliedAt: appliedAt[m.Version],
+			})
+		}
+
+		return nil
+	}, TxOptions{Mode: Deferred, ReadOnly: true})
+
+	return status, err
+}
+
+// run runs fn in an exclusive transaction, with the current
+// version of the database.
+func (mg *Migrator) run(ctx context.Context, fn func(tx *Tx, version int) error) error {
+	if mg.Table == "" {
+		return errors.New("migration table name is empty")
+	}
+
+	mode := Exclusive
+	if mg.DryRun {
+		mode = Deferred
+	}
+
+	tx, err := mg.db.BeginTx(ctx, TxOptions{Mode: mode})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !mg.DryRun {
+		res := tx.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s(version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TEXT NOT NULL)`,
+			quoteIdent(mg.Table)))
+		if res.Error() != nil {
+			return res.Error()
+		}
+	}
+
+	version, err := mg.version(tx)
+	if err != nil {
+		return err
+	}
+	if n := len(mg.migrations); n > 0 && version > mg.migrations[n-1].Version {
+		return fmt.Errorf("the database is at version %d; the last migration is %d", version, mg.migrations[n-1].Version)
+	}
+
+	errRun := fn(tx, version)
+
+	// the migrations before a failed one are kept
+	if mg.DryRun {
+		return errors.Join(errRun, tx.Rollback())
+	}
+
+	return errors.Join(errRun, tx.Commit())
+}
+
+// apply runs the script of a migration in a savepoint; then sets the
+// user_version and updates the history table.
+func (mg *Migrator) apply(tx *Tx, m Migration, script string, version int, record func() error) error {
+	sp, err := tx.Savepoint(fmt.Sprintf("migration_%d", m.Version))
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecScript(script); err == nil {
+		res := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+		if err = res.Error(); err == nil {
+			err = record()
+		}
+	}
+
+	if err != nil {
+		sp.Rollback()
+		return err
+	}
+
+	return sp.Release()
+}
+
+// version is the user_version of the database.
+func (mg *Migrator) version(tx *Tx) (int, error) {
+	rs, err := tx.Query("PRAGMA user_version")
+	if err != nil {
+		return 0, err
+	}
+	defer rs.Close()
+
+	var version int
+	if rs.Next() {
+		err = rs.Scan(&version)
+	}
+	if err == nil {
+		err = rs.Err()
+	}
+
+	return version, err
+}
+
+// history is the applied_at of the versions in the history
+// table; it is empty, if the table does not exist.
+func (mg *Migrator) history(tx *Tx) (map[int]time.Time, error) {
+	m := make(map[int]time.Time)
+
+	rs, err := tx.Query(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, mg.Table)
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	if rs.Next() {
+		rs.Scan(&n)
+	}
+	rs.Close()
+	if n == 0 {
+		return m, nil
+	}
+
+	rs, err = tx.Query(fmt.Sprintf(`SELECT version, applied_at FROM %s`, quoteIdent(mg.Table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		var version int
+		var appliedAt string
+		if err := rs.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		m[version], _ = time.Parse(time.RFC3339, appliedAt)
+	}
+
+	return m, rs.Err()
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+var testMigrations = fstest.MapFS{
+	"m/0001_person.up.sql":    {Data: []byte("create table person(id integer primary key, name text);")},
+	"m/0001_person.down.sql":  {Data: []byte("drop table person;")},
+	"m/0002_address.up.sql":   {Data: []byte("create table address(person_id, city);\ninsert into person(name) values('Jane');")},
+	"m/0002_address.down.sql": {Data: []byte("drop table address;\ndelete from person;")},
+	"m/README.md":             {Data: []byte("not a migration")},
+}
+
+func TestMigrator(t *testing.T) {
+	db := openTestDB(t, false)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, testMigrations, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Migrations()); n != 2 {
+		t.Fatalf("got %d migrations, want 2", n)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("applied %d migrations, want 2", len(applied))
+	}
+	if v := count(t, db, "PRAGMA user_version"); v != 2 {
+		t.Fatalf("got version %d, want 2", v)
+	}
+
+	// nothing to do
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("got %v, %v", applied, err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("reverted %v", reverted)
+	}
+	if v := count(t, db, "PRAGMA user_version"); v != 1 {
+		t.Fatalf("got version %d, want 1", v)
+	}
+
+	st, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st) != 2 || !st[0].Applied || st[1].Applied {
+		t.Fatalf("got status %v", st)
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	db := openTestDB(t, false)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"m/0001_a.up.sql": {Data: []byte("create table a(x);")},
+		"m/0002_b.up.sql": {Data: []byte("create table b(x);\ninsert into nosuchtable values(1);")},
+	}
+	m, err := NewMigrator(db, fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatal("no error for a failing migration")
+	}
+
+	// the failed migration is rolled back; the one before it is kept
+	if v := count(t, db, "PRAGMA user_version"); v != 1 {
+		t.Fatalf("got version %d, want 1", v)
+	}
+	if n := count(t, db, "select count(*) from sqlite_master where name = 'a'"); n != 1 {
+		t.Fatal("the table of the first migration is missing")
+	}
+	if n := count(t, db, "select count(*) from sqlite_master where name = 'b'"); n != 0 {
+		t.Fatal("the table of the failed migration exists")
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdio.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Named parameters (:name, @name or $name) can be bound by
+// passing one of the following as the place-holders:
+//
+//	--a map[string]any; e.g. map[string]any{"id": 1, "name": "abc"}
+//	--a struct (or a pointer to a struct); the db tag of a field
+//	  is its parameter name, otherwise the name of the field. A
+//	  field tagged with db:"-" is skipped.
+//	--sql.Named() values; e.g. sql.Named("id", 1), sql.Named("name", "abc")
+//
+// The names are matched without their prefix; i.e. "id" binds to
+// :id, @id or $id. Every parameter must have a value; a key of a map
+// or a sql.Named value that does not match any parameter is an error
+// (the fields of a struct are not required to be used). With more
+// than one statement (see ExecScript()), a value must match the
+// parameter of at least one of them; checked before any of them runs.
+//
+// Example:
+//
+//	db.Exec("insert into person(id, name) values(:id, :name)",
+//		map[string]any{"id": 1, "name": "abc"})
+
+// namedValues returns the named values of placeHolders, if they
+// are passed as a map, a struct or sql.Named() values. strict is
+// true, if every value must match a parameter.
+func namedValues(placeHolders []any) (m map[string]any, strict bool, ok bool, err error) {
+
+	if len(placeHolders) == 0 {
+		return nil, false, false, nil
+	}
+
+	if _, isNamed := placeHolders[0].(sql.NamedArg); isNamed {
+		m = make(map[string]any, len(placeHolders))
+		for i := range placeHolders {
+			na, isNamed := placeHolders[i].(sql.NamedArg)
+			if !isNamed {
+				return nil, false, false, fmt.Errorf("named and positional values can not be mixed; arg %d is not a sql.NamedArg", i)
+			}
+			m[trimParamPrefix(na.Name)] = na.Value
+		}
+		return m, true, true, nil
+	}
+
+	if len(placeHolders) != 1 {
+		return nil, false, false, nil
+	}
+
+	switch v := placeHolders[0].(type) {
+	case map[string]any:
+		m = make(map[string]any, len(v))
+		for k := range v {
+			m[trimParamPrefix(k)] = v[k]
+		}
+		return m, true, true, nil
+
+	case time.Time, *time.Time, driver.Valuer:
+		// a single value; e.g. sql.NullString
+		return nil, false, false, nil
+	}
+
+	rv := reflect.ValueOf(placeHolders[0])
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+			return nil, false, false, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false, false, nil
+	}
+
+	m = make(map[string]any)
+	structParams(rv, m)
+
+	return m, false, true, nil
+}
+
+// structParams adds the exported fields of a struct to m by their
+// db tag (or field name); the fields of embedded structs are included.
+func structParams(rv reflect.Value, m map[string]any) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+				structParams(fv, m)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if _, exists := m[name]; exists {
+			// the outer field wins
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				m[name] = nil
+				continue
+			}
+			fv = fv.Elem()
+		}
+		m[name] = fv.Interface()
+	}
+}
+
+// trimParamPrefix removes the :, @ or $ prefix of a parameter name.
+func trimParamPrefix(name string) string {
+	if len(name) > 0 && strings.ContainsRune(":@$", rune(name[0])) {
+		return name[1:]
+	}
+	return name
+}
+
+// bindNamed places the named values in the position of their
+// parameters in the statement and returns them as positional values.
+func (s *Stmt) bindNamed(m map[string]any, strict bool) ([]any, error) {
+
+	paramCnt := int(C.sqlite3_bind_parameter_count(s.cStmt))
+	vals := make([]any, paramCnt)
+	used := make(map[string]bool, len(m))
+
+	var missing []string
+	for i := range paramCnt {
+		p := C.sqlite3_bind_parameter_name(s.cStmt, C.int(i+1))
+		if p == nil {
+			return nil, fmt.Errorf("parameter %d is positional (?); it can not be bound by name", i+1)
+		}
+
+		paramName := C.GoString(p)
+		if strings.HasPrefix(paramName, "?") {
+			return nil, fmt.Errorf("parameter %s is positional; it can not be bound by name", paramName)
+		}
+
+		name := trimParamPrefix(paramName)
+		v, ok := m[name]
+		if !ok && !strict {
+			// struct fields; try the name regardless of its case
+			for k := range m {
+				if strings.EqualFold(k, name) {
+					v, ok = m[k]
+					name = k
+					break
+				}
+			}
+		}
+		if !ok {
+			missing = append(missing, paramName)
+			continue
+		}
+
+		used[name] = true
+		vals[i] = v
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing value for parameter(s): %s", strings.Join(missing, ", "))
+	}
+
+	if strict {
+		if err := checkUnused(m, used); err != nil {
+			return nil, err
+		}
+	}
+
+	return vals, nil
+}
+
+// checkUnused returns an error, if any of the named values
+// is not used by a parameter.
+func checkUnused(m map[string]any, used map[string]bool) error {
+	var unused []string
+	for k := range m {
+		if !used[k] {
+			unused = append(unused, k)
+		}
+	}
+	if len(unused) > 0 {
+		slices.Sort(unused)
+		return fmt.Errorf("named value(s) not used in the sql statement: %s", strings.Join(unused, ", "))
+	}
+
+	return nil
+}
+
+// usedValues returns the named values of m that are
+// used by the parameters of the statement.
+func (s *Stmt) usedValues(m map[string]any) map[string]any {
+	used := make(map[string]any)
+
+	paramCnt := int(C.sqlite3_bind_parameter_count(s.cStmt))
+	for i := range paramCnt {
+		p := C.sqlite3_bind_parameter_name(s.cStmt, C.int(i+1))
+		if p == nil {
+			continue
+		}
+		name := trimParamPrefix(C.GoString(p))
+		if v, ok := m[name]; ok {
+			used[name] = v
+		}
+	}
+
+	return used
+}
+
+// paramNames returns the names of the named parameters (without
+// their prefix) of the statements of sqlx; the string literals,
+// quoted identifiers and comments are skipped, as by the tokenizer
+// of sqlite3. See: https://www.sqlite.org/lang_expr.html#varparam
+func paramNames(sqlx string) map[string]bool {
+	names := make(map[string]bool)
+
+	isIdent := func(c byte) bool {
+		return c == '_' || c >= 0x80 ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+	}
+
+	for i := 0; i < len(sqlx); i++ {
+		switch c := sqlx[i]; c {
+		case '\'', '"', '`', '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			for i++; i < len(sqlx); i++ {
+				if sqlx[i] != end {
+					continue
+				}
+				if end != ']' && i+1 < len(sqlx) && sqlx[i+1] == end {
+					// an escaped quote; e.g. 'it''s'
+					i++
+					continue
+				}
+				break
+			}
+
+		case '-':
+			if i+1 < len(sqlx) && sqlx[i+1] == '-' {
+				for i < len(sqlx) && sqlx[i] != '\n' {
+					i++
+				}
+			}
+
+		case '/':
+			if i+1 < len(sqlx) && sqlx[i+1] == '*' {
+				end := strings.Index(sqlx[i+2:], "*/")
+				if end < 0 {
+					return names
+				}
+				i += end + 3
+			}
+
+		case ':', '@', '$':
+			if c == '$' && i > 0 && isIdent(sqlx[i-1]) {
+				// a part of an identifier; e.g. price$usd
+				continue
+			}
+			j := i + 1
+			for j < len(sqlx) {
+				if isIdent(sqlx[j]) {
+					j++
+				} else if c == '$' && strings.HasPrefix(sqlx[j:], "::") {
+					j += 2
+				} else {
+					break
+				}
+			}
+			if j > i+1 {
+				names[sqlx[i+1:j]] = true
+			}
+			i = j - 1
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+type namedBase struct {
+	ID int64 `db:"id"`
+}
+
+type namedPerson struct {
+	namedBase
+	Name    string  `db:"name"`
+	Email   *string `db:"email"`
+	Ignored string  `db:"-"`
+	Extra   int     // not used by the statement
+}
+
+func TestNamedParams(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table person(id, name, email)")
+
+	const insert = "insert into person(id, name, email) values(:id, @name, $email)"
+
+	mustExec(t, db, insert, map[string]any{"id": 1, "name": "a", ":email": "a@x"})
+	mustExec(t, db, insert, sql.Named("id", 2), sql.Named("name", "b"), sql.Named("email", nil))
+	mustExec(t, db, insert, namedPerson{namedBase: namedBase{ID: 3}, Name: "c"})
+
+	if _, err := db.ExecuteNonQuery("update person set name = :name where id = :id",
+		map[string]any{"id": 3, "name": "cc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dt, err := db.GetDataTable("select name from person where id = $id", sql.Named("id", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt.Rows) != 1 || dt.Rows[0]["name"] != "cc" {
+		t.Fatalf("got %v, want cc", dt.Rows)
+	}
+
+	if n := count(t, db, "select count(*) from person where email is null"); n != 2 {
+		t.Fatalf("got %d NULL emails, want 2", n)
+	}
+
+	rows, err := db.Query("select name from person where id >= :id order by id", map[string]any{"id": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if strings.Join(names, ",") != "b,cc" {
+		t.Fatalf("got %v, want [b cc]", names)
+	}
+}
+
+func TestNamedParamsErrors(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table person(id, name)")
+
+	const insert = "insert into person(id, name) values(:id, :name)"
+
+	tests := []struct {
+		name string
+		sqlx string
+		args []any
+		want string
+	}{
+		{"missing", insert, []any{map[string]any{"id": 1}}, "missing value for parameter(s): :name"},
+		{"unused", insert, []any{map[string]any{"id": 1, "name": "a", "age": 2}}, "not used in the sql statement: age"},
+		{"mixed", insert, []any{sql.Named("id", 1), "a"}, "can not be mixed"},
+		{"positional", "insert into person(id, name) values(?, :name)", []any{map[string]any{"name": "a"}}, "positional"},
+	}
+
+	for _, tt := range tests {
+		res := db.Exec(tt.sqlx, tt.args...)
+		err := res.Error()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if n := count(t, db, "select count(*) from person"); n != 0 {
+		t.Fatalf("got %d rows, want 0", n)
+	}
+}
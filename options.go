@@ -0,0 +1,403 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Option is a setting of a database opened by OpenWith().
+type Option func(c *openConfig) error
+
+// openConfig is the settings of OpenWith(); the zero value
+// opens an existing database read-write, as-is.
+type openConfig struct {
+	readOnly bool
+	create   bool
+	uri      bool
+	noGroup  bool
+	vfs      string
+
+	journalMode  JMode
+	synchronous  SyncMode
+	busyTimeout  *time.Duration
+	foreignKeys  *bool
+	cacheSize    *int
+	mmapSize     *int64
+	secureDelete *bool
+
+	// profile is the settings of WithProfile(); they are
+	// used for the options that are not given.
+	profile *openConfig
+
+	// given is the options with a value, by name; an option
+	// given twice with different values is a conflict.
+	given map[string]any
+}
+
+// OpenWith opens a database with typed options, instead of loose
+// PRAGMA strings; no PRAGMA is applied, other than the ones of the
+// options and of the profile (see WithProfile()). The options are
+// validated, and the conflicting ones (e.g. WithReadOnly() and
+// WithCreate()) are reported as an error before the database is
+// opened. e.g.
+//
+//	db, err := gosqlite.OpenWith(dbPath,
+//		gosqlite.WithCreate(),
+//		gosqlite.WithJournalMode(gosqlite.JounalMode().Wal),
+//		gosqlite.WithSynchronous(gosqlite.SynchronousMode().Normal),
+//		gosqlite.WithBusyTimeout(5*time.Second),
+//		gosqlite.WithForeignKeys(true))
+func OpenWith(dbFilePath string, opts ...Option) (*DB, error) {
+	var c openConfig
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	c.useProfile()
+
+	if err := c.validate(dbFilePath); err != nil {
+		return nil, err
+	}
+
+	if c.create && !c.uri && !c.inMemory(dbFilePath) {
+		// create all sub-dirs
+		if err := os.MkdirAll(filepath.Dir(dbFilePath), 0770); err != nil {
+			return nil, err
+		}
+	}
+
+	if !c.noGroup {
+		initGrouper()
+	}
+
+	d := initDB(dbFilePath, c.inMemory(dbFilePath))
+
+	fPath := C.CString(dbFilePath)
+	defer C.free(unsafe.Pointer(fPath))
+
+	var vfsNamePtr *C.char
+	if c.vfs != "" {
+		vfsNamePtr = C.CString(c.vfs)
+		defer C.free(unsafe.Pointer(vfsNamePtr))
+	}
+
+	res := C.sqlite3_open_v2(fPath, &d.DBHwnd, c.flags(), vfsNamePtr)
+	if err := getSQLiteErr(res, d.DBHwnd); err != nil {
+		C.sqlite3_close(d.DBHwnd)
+		return nil, err
+	}
+
+	d.Closed = false
+	d.vfsName = c.vfs
+	d.connected()
+
+	for _, p := range c.pragmas() {
+		if _, err := d.Execute(p); err != nil {
+			d.Close()
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	d.connPragmas = c.connPragmas()
+
+	if !c.noGroup {
+		DBGrp.Add(d)
+	}
+
+	return d, nil
+}
+
+// WithReadOnly opens the database read-only (SQLITE_OPEN_READONLY).
+func WithReadOnly() Option {
+	return func(c *openConfig) error {
+		c.readOnly = true
+		return c.setOnce("WithReadOnly()", true)
+	}
+}
+
+// WithCreate creates the database file (and its directory),
+// if it does not exist (SQLITE_OPEN_CREATE).
+func WithCreate() Option {
+	return func(c *openConfig) error {
+		c.create = true
+		return c.setOnce("WithCreate()", true)
+	}
+}
+
+// WithVFS opens the database with the named vfs; e.g. "unix-excl".
+// See: https://www.sqlite.org/vfs.html
+func WithVFS(name string) Option {
+	return func(c *openConfig) error {
+		if name == "" {
+			return errors.New("WithVFS(): vfs name is empty")
+		}
+		c.vfs = name
+		return c.setOnce("WithVFS()", name)
+	}
+}
+
+// WithJournalMode sets PRAGMA journal_mode; e.g.
+// WithJournalMode(JounalMode().Wal).
+func WithJournalMode(m JMode) Option {
+	return func(c *openConfig) error {
+		mode := JMode(strings.ToUpper(string(m)))
+		j := JounalMode()
+		switch mode {
+		case j.Delete, j.Truncate, j.Persist, j.Memory, j.Wal, j.Off:
+		default:
+			return fmt.Errorf("WithJournalMode(): invalid journal mode %q", m)
+		}
+		c.journalMode = mode
+		return c.setOnce("WithJournalMode()", mode)
+	}
+}
+
+// WithSynchronous sets PRAGMA synchronous; e.g.
+// WithSynchronous(SynchronousMode().Normal).
+func WithSynchronous(m SyncMode) Option {
+	return func(c *openConfig) error {
+		mode := SyncMode(strings.ToUpper(string(m)))
+		s := SynchronousMode()
+		switch mode {
+		case s.Off, s.Normal, s.Full, s.Extra:
+		default:
+			return fmt.Errorf("WithSynchronous(): invalid synchronous mode %q", m)
+		}
+		c.synchronous = mode
+		return c.setOnce("WithSynchronous()", mode)
+	}
+}
+
+// WithBusyTimeout makes the connections of the database wait up to
+// t for a lock held by another connection; see DB.SetBusyTimeout().
+func WithBusyTimeout(t time.Duration) Option {
+	return func(c *openConfig) error {
+		if t < 0 {
+			return errors.New("WithBusyTimeout(): busy timeout cannot be negative")
+		}
+		c.busyTimeout = &t
+		return c.setOnce("WithBusyTimeout()", t)
+	}
+}
+
+// WithForeignKeys sets PRAGMA foreign_keys.
+func WithForeignKeys(on bool) Option {
+	return func(c *openConfig) error {
+		c.foreignKeys = &on
+		return c.setOnce("WithForeignKeys()", on)
+	}
+}
+
+// WithCacheSize sets PRAGMA cache_size of every connection; n > 0
+// is the number of pages, and n < 0 is the size in KiB (e.g. -2000
+// is about 2MB).
+func WithCacheSize(n int) Option {
+	return func(c *openConfig) error {
+		c.cacheSize = &n
+		return c.setOnce("WithCacheSize()", n)
+	}
+}
+
+// WithMmapSize sets PRAGMA mmap_size (in bytes) of every
+// connection; 0 turns memory-mapped I/O off.
+func WithMmapSize(n int64) Option {
+	return func(c *openConfig) error {
+		if n < 0 {
+			return errors.New("WithMmapSize(): mmap size cannot be negative")
+		}
+		c.mmapSize = &n
+		return c.setOnce("WithMmapSize()", n)
+	}
+}
+
+// WithSecureDelete sets PRAGMA secure_delete.
+func WithSecureDelete(on bool) Option {
+	return func(c *openConfig) error {
+		c.secureDelete = &on
+		return c.setOnce("WithSecureDelete()", on)
+	}
+}
+
+// WithURI lets the path be a URI filename (SQLITE_OPEN_URI); e.g.
+// "file:data.db?cache=shared". See: https://www.sqlite.org/uri.html
+func WithURI() Option {
+	return func(c *openConfig) error {
+		c.uri = true
+		return nil
+	}
+}
+
+// WithoutGroupTracking does not add the database to DBGrp; so it is
+// not pinged, optimized or vacuumed by the group in the background.
+func WithoutGroupTracking() Option {
+	return func(c *openConfig) error {
+		c.noGroup = true
+		return nil
+	}
+}
+
+// setOnce records the value of an option; it fails, if the
+// option has already been given with another value.
+func (c *openConfig) setOnce(name string, v any) error {
+	if c.given == nil {
+		c.given = make(map[string]any)
+	}
+	if prev, ok := c.given[name]; ok && prev != v {
+		return fmt.Errorf("%s is given twice with different values (%v and %v)", name, prev, v)
+	}
+	c.given[name] = v
+
+	return nil
+}
+
+// validate checks the options against each other and the path.
+func (c *openConfig) validate(dbFilePath string) error {
+	if dbFilePath == "" {
+		return errors.New("database file path is empty")
+	}
+
+	if c.readOnly && c.create {
+		return fmt.Errorf("%s conflicts with %s: a read-only database cannot be created",
+			c.source("WithReadOnly()"), c.source("WithCreate()"))
+	}
+	if c.readOnly && c.journalMode != "" {
+		return fmt.Errorf("%s conflicts with %s: the journal mode of a read-only database cannot be changed",
+			c.source("WithReadOnly()"), c.source("WithJournalMode()"))
+	}
+
+	if !c.uri && strings.HasPrefix(dbFilePath, "file:") {
+		return fmt.Errorf("%q is a URI filename; it requires WithURI()", dbFilePath)
+	}
+
+	if c.inMemory(dbFilePath) {
+		j := JounalMode()
+		if c.journalMode != "" && c.journalMode != j.Memory && c.journalMode != j.Off {
+			return fmt.Errorf("%s conflicts with an in-memory database: journal mode %s; it can only be %s or %s",
+				c.source("WithJournalMode()"), c.journalMode, j.Memory, j.Off)
+		}
+	} else if !c.create && !c.uri && !fileOrDirExists(dbFilePath) {
+		return errors.New("database file does not exist; WithCreate() creates it")
+	}
+
+	if c.vfs != "" {
+		vfsNamePtr := C.CString(c.vfs)
+		defer C.free(unsafe.Pointer(vfsNamePtr))
+		if C.sqlite3_vfs_find(vfsNamePtr) == nil {
+			return fmt.Errorf("%s: no such vfs: %s", c.source("WithVFS()"), c.vfs)
+		}
+	}
+
+	return nil
+}
+
+// source is the name of an option in an error; or of the profile,
+// if the setting of the option is of the profile (see useProfile()).
+func (c *openConfig) source(option string) string {
+	if _, ok := c.given[option]; ok || c.profile == nil {
+		return option
+	}
+
+	return fmt.Sprintf("WithProfile(%s)", c.given["WithProfile()"])
+}
+
+// inMemory reports whether the path is of an in-memory database.
+func (c *openConfig) inMemory(dbFilePath string) bool {
+	if dbFilePath == ":memory:" {
+		return true
+	}
+
+	return c.uri && (strings.HasPrefix(dbFilePath, "file::memory:") ||
+		strings.Contains(dbFilePath, "mode=memory"))
+}
+
+// flags are the flags of sqlite3_open_v2().
+func (c *openConfig) flags() C.int {
+	flag := C.int(C.SQLITE_OPEN_EXRESCODE | C.SQLITE_OPEN_FULLMUTEX)
+
+	if c.readOnly {
+		flag |= C.SQLITE_OPEN_READONLY
+	} else {
+		flag |= C.SQLITE_OPEN_READWRITE
+	}
+	if c.create {
+		flag |= C.SQLITE_OPEN_CREATE
+	}
+	if c.uri {
+		flag |= C.SQLITE_OPEN_URI
+	}
+
+	return flag
+}
+
+// pragmas are the PRAGMA statements of the options, in the order
+// they are applied to the writer connection; the busy timeout
+// first, so that the rest wait for a locked database.
+func (c *openConfig) pragmas() []string {
+	var p []string
+
+	if c.busyTimeout != nil {
+		p = append(p, fmt.Sprintf("PRAGMA busy_timeout = %d", c.busyTimeout.Milliseconds()))
+	}
+	if c.journalMode != "" {
+		p = append(p, fmt.Sprintf("PRAGMA main.journal_mode = %s", c.journalMode))
+	}
+	if c.synchronous != "" {
+		p = append(p, fmt.Sprintf("PRAGMA main.synchronous = %s", c.synchronous))
+	}
+	if c.foreignKeys != nil {
+		p = append(p, fmt.Sprintf("PRAGMA foreign_keys = %s", onOff(*c.foreignKeys)))
+	}
+	if c.cacheSize != nil {
+		p = append(p, fmt.Sprintf("PRAGMA main.cache_size = %d", *c.cacheSize))
+	}
+	if c.mmapSize != nil {
+		p = append(p, fmt.Sprintf("PRAGMA main.mmap_size = %d", *c.mmapSize))
+	}
+	if c.secureDelete != nil {
+		p = append(p, fmt.Sprintf("PRAGMA main.secure_delete = %s", onOff(*c.secureDelete)))
+	}
+
+	return p
+}
+
+// connPragmas are the PRAGMA statements of the options that are
+// per connection; they are applied to the readers of the pool too.
+func (c *openConfig) connPragmas() []string {
+	var p []string
+
+	if c.busyTimeout != nil {
+		p = append(p, fmt.Sprintf("PRAGMA busy_timeout = %d", c.busyTimeout.Milliseconds()))
+	}
+	if c.cacheSize != nil {
+		p = append(p, fmt.Sprintf("PRAGMA main.cache_size = %d", *c.cacheSize))
+	}
+	if c.mmapSize != nil {
+		p = append(p, fmt.Sprintf("PRAGMA main.mmap_size = %d", *c.mmapSize))
+	}
+
+	return p
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+
+	return "OFF"
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenWith(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "test.db")
+
+	db, err := OpenWith(path,
+		WithCreate(),
+		WithJournalMode(JounalMode().Wal),
+		WithSynchronous(SynchronousMode().Normal),
+		WithBusyTimeout(2*time.Second),
+		WithForeignKeys(true),
+		WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := db.EffectivePragmas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"journal_mode": "wal",
+		"synchronous":  "NORMAL",
+		"busy_timeout": "2000",
+		"foreign_keys": "ON",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Fatalf("%s: got %q, want %q", k, m[k], v)
+		}
+	}
+}
+
+func TestOpenWithErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.db")
+
+	tests := []struct {
+		name string
+		path string
+		opts []Option
+		want string
+	}{
+		{"no file", missing, nil, "WithCreate()"},
+		{"read-only create", missing, []Option{WithReadOnly(), WithCreate()},
+			"WithReadOnly() conflicts with WithCreate()"},
+		{"read-only journal", missing, []Option{WithReadOnly(), WithJournalMode(JounalMode().Wal)},
+			"WithReadOnly() conflicts with WithJournalMode()"},
+		{"memory journal", ":memory:", []Option{WithJournalMode(JounalMode().Wal)},
+			"WithJournalMode() conflicts with an in-memory database"},
+		{"given twice", missing, []Option{WithSynchronous(SynchronousMode().Full), WithSynchronous(SynchronousMode().Off)},
+			"WithSynchronous() is given twice"},
+		{"invalid journal", missing, []Option{WithJournalMode("bogus")}, "invalid journal mode"},
+		{"no vfs", missing, []Option{WithCreate(), WithVFS("no-such-vfs")}, "WithVFS(): no such vfs"},
+		{"uri", "file:" + missing, []Option{WithCreate()}, "WithURI()"},
+
+		// the setting is of the profile, not of an option
+		{"profile journal", ":memory:", []Option{WithProfile(ProfileDurable)},
+			"WithProfile(durable) conflicts with an in-memory database"},
+		{"profile read-only", missing, []Option{WithProfile(ProfileReadOnly), WithCreate()},
+			"WithProfile(read-only) conflicts with WithCreate()"},
+		{"read-only profile journal", missing, []Option{WithReadOnly(), WithProfile(ProfileFast)},
+			"WithReadOnly() conflicts with WithProfile(fast)"},
+	}
+
+	for _, tt := range tests {
+		db, err := OpenWith(tt.path, tt.opts...)
+		if err == nil {
+			db.Close()
+			t.Fatalf("%s: no error", tt.name)
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: got %q, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOpenWithProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// the options take precedence over the profile
+	db, err := OpenWith(path,
+		WithCreate(),
+		WithProfile(ProfileDurable),
+		WithSynchronous(SynchronousMode().Full),
+		WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := db.EffectivePragmas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["journal_mode"] != "wal" || m["synchronous"] != "FULL" {
+		t.Fatalf("got journal_mode %s, synchronous %s; want wal, FULL", m["journal_mode"], m["synchronous"])
+	}
+
+	if _, err := OpenWith(path, WithProfile(ProfileFast), WithProfile(ProfileSecure)); err == nil {
+		t.Fatal("no error for two profiles")
+	}
+}
@@ -0,0 +1,654 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// The connection pool of a DB has one writer connection (DB.DBHwnd)
+// and, in WAL mode, read-only reader connections that are opened on
+// demand; so that queries (Query(), GetDataTable(), ...) run in
+// parallel to each other and to the writer. The pool follows the
+// settings of DB.ConnPool:
+//
+//   - MaxOpenConns: max connections, incl. the writer (0 means unlimited);
+//     1 means the writer only.
+//   - MaxIdleConns: max idle reader connections kept open (0 means unlimited).
+//   - MaxLifetime: max time a reader connection is re-used (0 means unlimited).
+//   - MaxIdleTime: max time a reader connection is idle before it is closed
+//     (0 means unlimited).
+//   - MaxConcurrentRequests: max reads and writes in progress at the same
+//     time; more requests wait (0 means unlimited).
+//
+// Reads run on the writer connection, if the database is not in WAL
+// mode, is in-memory, or the writer connection is in a transaction
+// other than a Tx (e.g. of TxBegin()); a read on the writer waits
+// for an open Tx (see BeginTx()), so it does not see its changes.
+// See: https://www.sqlite.org/wal.html#concurrency
+
+// connPool is the pool of reader connections of a DB.
+type connPool struct {
+	mu      sync.Mutex
+	idle    []*poolConn
+	numOpen int // readers; idle or in use
+	active  int // requests in progress; see enterRequest()
+	seqNo   uint
+	closed  bool
+
+	// conns are the live connections (see DB.Connections);
+	// the writer is the first one.
+	conns []Connection
+
+	// released is closed (and renewed) when a reader
+	// or a request slot is released.
+	released chan struct{}
+
+	// wal is whether the database is in WAL mode;
+	// walChecked is false until it is read.
+	wal        bool
+	walChecked bool
+
+	pruneTimer *time.Timer
+}
+
+// poolConn is a reader connection of the pool.
+type poolConn struct {
+	// db is the view of the DB on this connection;
+	// i.e. with its DBHwnd, statement cache and lock.
+	db       *DB
+	seqNo    uint
+	created  time.Time
+	lastUsed time.Time
+
+	// regGen is the generation of the registry (see
+	// connRegistry) when the connection was opened.
+	regGen uint64
+
+	// busyGen is the generation of the busy settings
+	// of the connection; see applyBusy().
+	busyGen uint64
+}
+
+func newConnPool() *connPool {
+	return &connPool{released: make(chan struct{})}
+}
+
+// connected adds the writer connection to DB.Connections;
+// after the database is opened.
+func (d *DB) connected() {
+	p := d.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seqNo++
+	p.conns = []Connection{{
+		TimeConnected: d.TimeOpened,
+		ID:            fmt.Sprintf("%s-%d", d.Name, p.seqNo),
+		SeqNo:         p.seqNo,
+	}}
+	d.Connections = slices.Clone(p.conns)
+}
+
+// connLock is the lock of the connection of d; d is a reader of the
+// pool or the writer. Connections (and databases) do not share locks;
+// so independent databases run concurrently.
+func (d *DB) connLock() sync.Locker {
+	if d.connMu != nil {
+		return d.connMu
+	}
+
+	// not opened via initDB() (e.g. the empty DB returned
+	// by DBGroup.Get() on error); nothing to share.
+	return new(sync.Mutex)
+}
+
+// dbCounters are the request counters of a DB.
+type dbCounters struct {
+	seqNo    atomic.Uint64 // the last SeqNo of a DataTable or QueryResult
+	inFlight atomic.Int64  // requests in progress; see IsIdle()
+
+	// schemaGen is incremented when the schema is changed;
+	// see tableColumns().
+	schemaGen atomic.Uint64
+}
+
+// nextSeqNo is the SeqNo of the next request of DataTable or
+// QueryResult; in the order the requests are received by d.
+func (d *DB) nextSeqNo() uint {
+	if d.counters == nil {
+		return 0
+	}
+
+	return uint(d.counters.seqNo.Add(1))
+}
+
+// requestStarted counts a request in progress, until
+// the returned func is called; see IsIdle().
+func (d *DB) requestStarted() (done func()) {
+	c := d.counters
+	if c == nil {
+		return func() {}
+	}
+
+	c.inFlight.Add(1)
+
+	return func() { c.inFlight.Add(-1) }
+}
+
+// readConn gets a reader connection from the pool; nil if the query
+// is to run on the writer connection. It waits for a reader to be
+// released, if MaxOpenConns is reached.
+func (d *DB) readConn(ctx context.Context) (*poolConn, error) {
+	p := d.pool
+	if p == nil || d.isInMemory || d.Closed {
+		return nil, nil
+	}
+	if !d.inTx.Load() && !d.AutoCommit() {
+		// e.g. TxBegin(); the reads see its changes
+		return nil, nil
+	}
+
+	if !d.isWAL() {
+		return nil, nil
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errors.New("database is not open")
+		}
+
+		maxReaders := d.maxReaders()
+		if maxReaders == 0 {
+			// the writer only
+			p.mu.Unlock()
+			return nil, nil
+		}
+
+		d.pruneIdleConns(time.Now())
+		if len(p.idle) > 0 {
+			c := p.idle[len(p.idle)-1]
+			p.idle = p.idle[:len(p.idle)-1]
+			p.mu.Unlock()
+			d.applyBusy(c)
+			return c, nil
+		}
+
+		if maxReaders < 0 || p.numOpen < maxReaders {
+			p.numOpen++
+			p.seqNo++
+			seqNo := p.seqNo
+			p.mu.Unlock()
+
+			c, err := d.openReader(seqNo)
+			if err == nil {
+				d.applyBusy(c)
+			}
+
+			p.mu.Lock()
+			if err != nil {
+				p.numOpen--
+			} else {
+				p.conns = append(p.conns, Connection{
+					TimeConnected: c.created,
+					ID:            fmt.Sprintf("%s-%d", d.Name, seqNo),
+					SeqNo:         seqNo,
+					ReadOnly:      true,
+				})
+				d.Connections = slices.Clone(p.conns)
+			}
+			p.mu.Unlock()
+
+			return c, err
+		}
+
+		released := p.released
+		p.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// releaseConn returns a reader connection to the pool; it is closed,
+// if MaxIdleConns, MaxOpenConns or MaxLifetime is reached.
+func (d *DB) releaseConn(c *poolConn) {
+	p := d.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	c.lastUsed = now
+
+	if p.closed || !p.wal {
+		d.closeConn(c)
+	} else {
+		p.idle = append(p.idle, c)
+	}
+
+	d.pruneIdleConns(now)
+	p.signal()
+
+	if d.ConnPool.MaxIdleTime > 0 && len(p.idle) > 0 {
+		if p.pruneTimer == nil {
+			p.pruneTimer = time.AfterFunc(d.ConnPool.MaxIdleTime, func() {
+				p.mu.Lock()
+				defer p.mu.Unlock()
+				d.pruneIdleConns(time.Now())
+			})
+		} else {
+			p.pruneTimer.Reset(d.ConnPool.MaxIdleTime)
+		}
+	}
+}
+
+// pruneIdleConns closes the idle connections that have expired,
+// and the ones over MaxIdleConns or MaxOpenConns (e.g. after the
+// settings are changed); the caller must hold the lock of the pool.
+func (d *DB) pruneIdleConns(now time.Time) {
+	p := d.pool
+
+	idle := p.idle[:0]
+	for _, c := range p.idle {
+		if d.connExpired(c, now) {
+			d.closeConn(c)
+		} else {
+			idle = append(idle, c)
+		}
+	}
+	clear(p.idle[len(idle):])
+	p.idle = idle
+
+	// the oldest first
+	maxReaders := d.maxReaders()
+	for len(p.idle) > 0 &&
+		((d.ConnPool.MaxIdleConns > 0 && len(p.idle) > d.ConnPool.MaxIdleConns) ||
+			(maxReaders >= 0 && p.numOpen > maxReaders)) {
+		d.closeConn(p.idle[0])
+		p.idle[0] = nil
+		p.idle = p.idle[1:]
+	}
+}
+
+// maxReaders is the max number of reader connections; -1 means
+// unlimited. See ConnPool.MaxOpenConns.
+func (d *DB) maxReaders() int {
+	if d.ConnPool.MaxOpenConns > 0 {
+		return d.ConnPool.MaxOpenConns - 1
+	}
+
+	return -1
+}
+
+// connExpired reports whether a reader connection has reached
+// MaxLifetime or MaxIdleTime, or misses a registration of the
+// writer (e.g. a function).
+func (d *DB) connExpired(c *poolConn, now time.Time) bool {
+	if c.regGen != d.registry.generation() {
+		return true
+	}
+	if d.ConnPool.MaxLifetime > 0 && now.Sub(c.created) >= d.ConnPool.MaxLifetime {
+		return true
+	}
+	if d.ConnPool.MaxIdleTime > 0 && now.Sub(c.lastUsed) >= d.ConnPool.MaxIdleTime {
+		return true
+	}
+
+	return false
+}
+
+// openReader opens a read-only connection to the database file.
+func (d *DB) openReader(seqNo uint) (*poolConn, error) {
+	fPath := C.CString(d.filePath)
+	defer C.free(unsafe.Pointer(fPath))
+
+	var vfsNamePtr *C.char
+	if d.vfsName != "" {
+		vfsNamePtr = C.CString(d.vfsName)
+		defer C.free(unsafe.Pointer(vfsNamePtr))
+	}
+
+	var hwnd *C.sqlite3
+	res := C.sqlite3_open_v2(fPath, &hwnd,
+		C.SQLITE_OPEN_READONLY|
+			C.SQLITE_OPEN_EXRESCODE|
+			C.SQLITE_OPEN_FULLMUTEX,
+		vfsNamePtr)
+	if res != SQLITE_OK {
+		err := getSQLiteErr(res, hwnd)
+		C.sqlite3_close(hwnd)
+		return nil, err
+	}
+
+	// the per-connection settings of the writer
+	for _, p := range d.connPragmas {
+		sqlx := C.CString(p)
+		res = C.sqlite3_exec(hwnd, sqlx, nil, nil, nil)
+		C.free(unsafe.Pointer(sqlx))
+		if res != SQLITE_OK {
+			err := withSQL(getSQLiteErr(res, hwnd), p)
+			C.sqlite3_close(hwnd)
+			return nil, err
+		}
+	}
+
+	// the functions, etc. of the writer
+	regGen, err := d.registry.apply(hwnd)
+	if err != nil {
+		C.sqlite3_close(hwnd)
+		return nil, err
+	}
+
+	now := time.Now()
+	c := poolConn{
+		db: &DB{
+			DBHwnd:     hwnd,
+			Name:       d.Name,
+			UniqueName: d.UniqueName,
+			ConnString: d.ConnString,
+			TimeOpened: now,
+			filePath:   d.filePath,
+			vfsName:    d.vfsName,
+			stmtCache:  newStmtCache(d.stmtCache.capacity),
+			busy:       d.busy,
+			connMu:     new(connMutex),
+			counters:   d.counters,
+		},
+		seqNo:    seqNo,
+		created:  now,
+		lastUsed: now,
+		regGen:   regGen,
+	}
+
+	return &c, nil
+}
+
+// applyBusy sets SetBusyTimeout() or SetBusyHandler() of the
+// writer on a reader, if it changed since the reader was used.
+func (d *DB) applyBusy(c *poolConn) {
+	b := d.busy
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c.busyGen != b.gen && b.apply(c.db.DBHwnd) == nil {
+		c.busyGen = b.gen
+	}
+}
+
+// closeConn closes a reader connection; the caller must hold
+// the lock of the pool.
+func (d *DB) closeConn(c *poolConn) {
+	p := d.pool
+
+	c.db.stmtCache.clear()
+	C.sqlite3_close(c.db.DBHwnd)
+	c.db.DBHwnd = nil
+	c.db.Closed = true
+
+	p.numOpen--
+	p.conns = slices.DeleteFunc(p.conns, func(cn Connection) bool {
+		return cn.ReadOnly && cn.SeqNo == c.seqNo
+	})
+	d.Connections = slices.Clone(p.conns)
+}
+
+// closePool closes the idle reader connections; the ones in use
+// are closed when they are released.
+func (d *DB) closePool() {
+	p := d.pool
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.pruneTimer != nil {
+		p.pruneTimer.Stop()
+	}
+	for _, c := range p.idle {
+		d.closeConn(c)
+	}
+	p.idle = nil
+
+	p.conns = slices.DeleteFunc(p.conns, func(cn Connection) bool {
+		return !cn.ReadOnly
+	})
+	d.Connections = slices.Clone(p.conns)
+	p.signal()
+}
+
+// signal wakes up the requests that wait for a reader or for a
+// request slot; the caller must hold the lock of the pool.
+func (p *connPool) signal() {
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// enterRequest waits for a request slot, if MaxConcurrentRequests
+// is reached; leave must be called when the request is done.
+func (d *DB) enterRequest(ctx context.Context) (leave func(), err error) {
+	p := d.pool
+	if p == nil {
+		return func() {}, nil
+	}
+
+	for {
+		p.mu.Lock()
+		maxReq := d.ConnPool.MaxConcurrentRequests
+		if maxReq <= 0 || p.active < maxReq {
+			p.active++
+			p.mu.Unlock()
+
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					p.mu.Lock()
+					p.active--
+					p.signal()
+					p.mu.Unlock()
+				})
+			}, nil
+		}
+		released := p.released
+		p.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// isWAL reports whether the database is in WAL mode; it is read
+// from the writer connection once, and again after the journal
+// mode is changed (see checkJournalMode()).
+func (d *DB) isWAL() bool {
+	p := d.pool
+
+	p.mu.Lock()
+	if p.walChecked {
+		wal := p.wal
+		p.mu.Unlock()
+		return wal
+	}
+	p.mu.Unlock()
+
+	mu := d.connLock()
+	mu.Lock()
+	mode := d.journalMode()
+	mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.wal = strings.EqualFold(mode, "wal")
+	p.walChecked = true
+	if !p.wal {
+		// the readers would be blocked by the writer
+		for _, c := range p.idle {
+			d.closeConn(c)
+		}
+		p.idle = nil
+	}
+
+	return p.wal
+}
+
+// journalMode reads the journal mode of the main database;
+// the caller must hold the lock of the connection.
+func (d *DB) journalMode() string {
+	sqlx := C.CString("PRAGMA main.journal_mode")
+	defer C.free(unsafe.Pointer(sqlx))
+
+	var ppStmt *C.sqlite3_stmt
+	if C.sqlite3_prepare_v2(d.DBHwnd, sqlx, -1, &ppStmt, nil) != SQLITE_OK {
+		return ""
+	}
+	defer C.sqlite3_finalize(ppStmt)
+
+	if C.sqlite3_step(ppStmt) != SQLITE_ROW {
+		return ""
+	}
+
+	return C.GoString((*C.char)(unsafe.Pointer(C.sqlite3_column_text(ppStmt, 0))))
+}
+
+// checkJournalMode makes the pool read the journal mode again,
+// if sqlx changes it.
+func (d *DB) checkJournalMode(sqlx string) {
+	if d.pool == nil || !strings.Contains(strings.ToUpper(sqlx), "JOURNAL_MODE") {
+		return
+	}
+
+	d.pool.mu.Lock()
+	d.pool.walChecked = false
+	d.pool.mu.Unlock()
+}
+
+// isSchemaErr reports whether a query failed on a reader connection
+// because an object is known only to the writer connection; e.g. a
+// temp table or an attached database. Other errors (e.g. a syntax
+// error) are not run again on the writer.
+func isSchemaErr(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	if e.Code == SQLITE_SCHEMA {
+		return true
+	}
+	if e.Code != SQLITE_ERROR {
+		return false
+	}
+
+	for _, msg := range []string{"no such table", "no such function", "no such module",
+		"no such collation sequence", "unknown database"} {
+		if strings.Contains(e.Message, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readQuery runs a query on a reader connection; the reader is
+// returned to the pool when the Rows is closed.
+func (d *DB) readQuery(ctx context.Context, query string, placeHolders ...any) (*Rows, error) {
+	leave, err := d.enterRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := d.readConn(ctx)
+	if err != nil {
+		leave()
+		return nil, err
+	}
+
+	if c != nil {
+		rs, err := c.db.query(ctx, query, placeHolders...)
+		if err == nil {
+			rs.release = func() {
+				d.releaseConn(c)
+				leave()
+			}
+			return rs, nil
+		}
+		rs.Close()
+		d.releaseConn(c)
+		if !isSchemaErr(err) {
+			leave()
+			return rs, err
+		}
+		// run it on the writer
+	}
+
+	rs, err := d.query(ctx, query, placeHolders...)
+	if err != nil {
+		leave()
+		return rs, err
+	}
+	rs.release = leave
+	rs.txLock = &d.mutex
+
+	return rs, nil
+}
+
+// readDataTable runs fn on a reader connection, or on the writer
+// connection; see readConn().
+func (d *DB) readDataTable(ctx context.Context, fn func(r *DB) (*DataTable, error)) (*DataTable, error) {
+	leave, err := d.enterRequest(ctx)
+	if err != nil {
+		return new(DataTable), err
+	}
+	defer leave()
+
+	c, err := d.readConn(ctx)
+	if err != nil {
+		return new(DataTable), err
+	}
+
+	if c != nil {
+		dt, err := fn(c.db)
+		d.releaseConn(c)
+		if !isSchemaErr(err) {
+			if dt != nil {
+				dt.db = d
+			}
+			return dt, err
+		}
+		// run it on the writer
+	}
+
+	// it waits for an open Tx; see BeginTx().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return fn(d)
+}
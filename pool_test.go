@@ -0,0 +1,128 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolReaders(t *testing.T) {
+	db := openTestDB(t, true)
+	db.ConnPool.MaxOpenConns = 3 // the writer + 2 readers
+	mustExec(t, db, "create table t(a)")
+	mustExec(t, db, "insert into t values(1), (2), (3)")
+
+	// the rows keep their reader until they are closed
+	rs, err := db.Query("select a from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dt, err := db.GetDataTable("select a from t")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(dt.Rows) != 3 {
+				t.Errorf("got %d rows, want 3", len(dt.Rows))
+			}
+		}()
+	}
+	wg.Wait()
+	rs.Close()
+
+	readers := 0
+	for _, c := range db.Connections {
+		if c.ReadOnly {
+			readers++
+		}
+	}
+	if readers == 0 || readers > 2 {
+		t.Fatalf("got %d readers, want 1 or 2", readers)
+	}
+}
+
+func TestPoolSeesCommits(t *testing.T) {
+	db := openTestDB(t, true)
+	mustExec(t, db, "create table t(a)")
+
+	for i := range 3 {
+		mustExec(t, db, "insert into t values(?)", i)
+		if n := count(t, db, "select count(*) from t"); n != int64(i+1) {
+			t.Fatalf("got %d rows, want %d", n, i+1)
+		}
+	}
+}
+
+func TestPoolWriterFallback(t *testing.T) {
+	db := openTestDB(t, true)
+
+	// a temp table is only known to the writer
+	mustExec(t, db, "create temp table tt(a)")
+	mustExec(t, db, "insert into tt values(1)")
+
+	dt, err := db.GetDataTable("select a from tt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(dt.Rows))
+	}
+
+	rs, err := db.Query("select a from tt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.Next() {
+		t.Fatalf("no row: %v", rs.Err())
+	}
+	rs.Close()
+
+	// other errors are returned as they are
+	_, err = db.GetDataTable("selec a from tt")
+	var e *Error
+	if !errors.As(err, &e) || e.Code != SQLITE_ERROR {
+		t.Fatalf("got %v, want a syntax error", err)
+	}
+}
+
+func TestPoolMaxLifetime(t *testing.T) {
+	db := openTestDB(t, true)
+	db.ConnPool.MaxLifetime = time.Nanosecond
+	mustExec(t, db, "create table t(a)")
+	mustExec(t, db, "insert into t values(1), (2)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the reader is closed when the rows end (by Next())
+	for range 5 {
+		rs, err := db.QueryWithContext(ctx, "select a from t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for rs.Next() {
+			n++
+		}
+		if err := rs.Err(); err != nil {
+			t.Fatal(err)
+		}
+		rs.Close()
+		if n != 2 {
+			t.Fatalf("got %d rows, want 2", n)
+		}
+	}
+}
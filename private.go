@@ -10,7 +10,6 @@ package gosqlite
 //#include "sqlite3.h"
 import "C"
 import (
-	"errors"
 	"fmt"
 	"path"
 	"strings"
@@ -21,7 +20,7 @@ import (
 // initDB creates a new instance of DB.
 // If dbFilePath is emtpy an in-memory DB
 // is assumed.
-func initDB(dbFilePath string, inMemory ...bool) DB {
+func initDB(dbFilePath string, inMemory ...bool) *DB {
 
 	var inMem InMemoryObjects
 
@@ -30,7 +29,9 @@ func initDB(dbFilePath string, inMemory ...bool) DB {
 		x = true
 	}
 
-	var db = DB{
+	hooks := newHookState()
+
+	var db = &DB{
 		DBHwnd:     nil,
 		filePath:   dbFilePath,
 		UniqueName: createHash(dbFilePath),
@@ -41,16 +42,22 @@ func initDB(dbFilePath string, inMemory ...bool) DB {
 			MaxLifetime:           0, /* 0 means unlimited */
 			MaxIdleTime:           0, /* 0 means unlimited */
 			MaxConcurrentRequests: 100000},
-		Closed:                  true,
-		TimeOpened:              time.Now(),
-		ConnString:              fmt.Sprintf("file_path=%s", dbFilePath),
-		Name:                    strings.TrimSuffix(path.Base(dbFilePath), ".sqlite"), /* default */
-		getResultSetConnections: 0,
-		Post:                    postInit(),
+		Closed:     true,
+		TimeOpened: time.Now(),
+		ConnString: fmt.Sprintf("file_path=%s", dbFilePath),
+		Name:       strings.TrimSuffix(path.Base(dbFilePath), ".sqlite"), /* default */
+		Post:       postInit(),
+		stmtCache:  newStmtCache(defaultStmtCacheSize),
+		busy:       newBusyState(),
+		pool:       newConnPool(),
+		connMu:     &connMutex{hooks: hooks},
+		counters:   new(dbCounters),
+		registry:   new(connRegistry),
+		hooks:      hooks,
 	}
 
-	db.intfce = &db
-	inMem.db = &db
+	db.intfce = db
+	inMem.db = db
 	db.InMemory = inMem
 
 	//initQueue(&db)
@@ -116,29 +123,6 @@ func fixPragmaTextAndOrder(pragArry []string) []string {
 	return pragArry
 }
 
-// removeFromResultQueue removes a query request
-// from the global mResultQueue.
-func (d *DB) removeFromResultQueue(queryID string) {
-
-	if len(mResultQueue) == 0 {
-		return
-	}
-	mCMutex.Lock()
-	for i := 0; i < len(mResultQueue); i++ {
-		if mResultQueue[i].QueryID == queryID {
-			mResultQueue[len(mResultQueue)-1], mResultQueue[i] = mResultQueue[i], mResultQueue[len(mResultQueue)-1]
-			mResultQueue = mResultQueue[:len(mResultQueue)-1]
-			break
-		}
-	}
-	mCMutex.Unlock()
-}
-
-// decrementActiveConn derements getResultSetConnections
-func (d *DB) decrementActiveConn() {
-	d.getResultSetConnections--
-}
-
 // getIndexColumns parses a list of columns that
 // are indexed from a create-table sql statement.
 func (d *DB) getIndexColumns(creatTableSQL string) []string {
@@ -329,84 +313,6 @@ func getTableNameFromSQLQuery(sqlQuery string) string {
 	return tblName
 }
 
-// waiForQueryResult waits for the exec callback to write the result
-// (for one row). It retreives the result-row when available and
-// adds it to the global map; it will remove the cached array-element
-// once query is done and ready to be returned to the result-set.
-func (d *DB) waiForQueryResult(queryID *string, timeoutSec int) QueryResult {
-
-	var q QueryResult
-
-	if timeoutSec == 0 {
-		// this is a rough estimation of the below cycle.
-		timeoutSec = 1500000 * 60 * 60 * 5 // 5 minutes; UNDONE: make the timeout configurable
-	}
-
-	j := 0
-	for {
-		if j > timeoutSec {
-			q.Err = errors.New("query timed out")
-			return q
-		}
-		var qLen int
-		// only lock the counter, otherwise
-		// the below loop will run slowly.
-		mCMutex.Lock()
-		qLen = len(mResultQueue)
-		mCMutex.Unlock()
-
-		for i := 0; i < qLen; i++ {
-			if i >= len(mResultQueue) {
-				break
-			}
-			if i < len(mResultQueue) && len(mResultQueue) > 0 &&
-				mResultQueue[i].QueryID == *queryID &&
-				(mResultQueue[i].Finished || mResultQueue[i].Err != nil) {
-				// save in a variable immediately; o rapid calls, the
-				// index goes out-of-bound!
-				mq := mResultQueue[i]
-				q = QueryResult{
-					SeqNo:       mq.SeqNo,
-					ResultTable: mq.Result,
-					QueryID:     mq.QueryID,
-					Err:         mq.Err, /* out-of-bound array index err may heppen if not using the var above */
-					TimeStarted: mq.TimeStarted,
-					TimeEnded:   mq.TimeEnded}
-
-				// this statement is repeated here for
-				// rappid calls
-				if i >= len(mResultQueue) {
-					break
-				}
-
-				if len(q.ResultTable) > 0 {
-					colLen := len(q.ResultTable[0])
-					q.Columns = make([]Column, colLen)
-					p := 0
-					for k, v := range q.ResultTable[0] {
-						q.Columns[p] = Column{
-							Name:     k,
-							Ordinal:  p,
-							DataType: GetSQLiteDataType(v),
-						}
-						p++
-						if p >= colLen {
-							break
-						}
-					}
-				}
-
-				if len(mResultQueue) > 0 && i < len(mResultQueue) {
-					mResultQueue[i].Received = true
-					d.removeFromResultQueue(*queryID)
-				}
-				return q
-			}
-		}
-		j++
-	}
-}
-
 // getStmtColVal gets a single column's value in an SQL
 // statement based on its ordinal position.
 func (d *DB) getStmtColVal(s *Stmt, colIndx int) any {
@@ -450,20 +356,6 @@ func getSQLiteErr(res C.int, dbHwnd *C.sqlite3) error {
 	if int(res) == 0 {
 		return nil
 	} else {
-		return errors.New(C.GoString(C.sqlite3_errmsg(dbHwnd)))
-	}
-}
-
-// processRequestQueue loops thru the global result
-// queue and calls execWithResults() on requests that
-// have not yet been started.
-func (d *DB) processRequestQueue() {
-	for i := 0; i < len(mResultQueue); i++ {
-		if !mResultQueue[i].Processed && !mResultQueue[i].Received && !mResultQueue[i].Started {
-			mResultQueue[i].Started = true
-			mResultQueue[i].TimeStarted = time.Now()
-
-			d.execWithResults(mResultQueue[i].QueryID, mResultQueue[i].SQLStmt)
-		}
+		return newError(res, dbHwnd)
 	}
 }
@@ -0,0 +1,229 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Profile is a named set of open options; see WithProfile(). No
+// PRAGMA is applied at open, unless a profile or an option asks
+// for it.
+type Profile struct {
+	Name    string
+	options []Option
+}
+
+var (
+	// ProfileDurable is for data that must survive a power loss or
+	// a crash of the OS, with good write speed: WAL journal and
+	// synchronous = NORMAL. A transaction is durable after the next
+	// checkpoint; readers do not block the writer.
+	// See: https://www.sqlite.org/wal.html
+	ProfileDurable = Profile{Name: "durable", options: []Option{
+		WithJournalMode(JounalMode().Wal),
+		WithSynchronous(SynchronousMode().Normal),
+	}}
+
+	// ProfileFast is the former default of OpenV2(): TRUNCATE journal,
+	// synchronous = OFF and secure_delete = ON. It is fast, but the
+	// last transactions (or the whole database) can be lost on a power
+	// loss or a crash of the OS.
+	ProfileFast = Profile{Name: "fast", options: []Option{
+		WithJournalMode(JounalMode().Truncate),
+		WithSynchronous(SynchronousMode().Off),
+		WithSecureDelete(true),
+	}}
+
+	// ProfileSecure overwrites deleted content (secure_delete = ON),
+	// deletes the rollback journal after every transaction (DELETE),
+	// syncs on every commit (synchronous = FULL) and enforces foreign
+	// keys.
+	ProfileSecure = Profile{Name: "secure", options: []Option{
+		WithJournalMode(JounalMode().Delete),
+		WithSynchronous(SynchronousMode().Full),
+		WithSecureDelete(true),
+		WithForeignKeys(true),
+	}}
+
+	// ProfileReadOnly opens the database read-only, as-is.
+	ProfileReadOnly = Profile{Name: "read-only", options: []Option{
+		WithReadOnly(),
+	}}
+)
+
+// WithProfile applies the settings of a profile; the options given
+// to OpenWith() take precedence over the ones of the profile. e.g.
+//
+//	db, err := gosqlite.OpenWith(dbPath,
+//		gosqlite.WithProfile(gosqlite.ProfileDurable),
+//		gosqlite.WithSynchronous(gosqlite.SynchronousMode().Full))
+func WithProfile(p Profile) Option {
+	return func(c *openConfig) error {
+		if err := c.setOnce("WithProfile()", p.Name); err != nil {
+			return err
+		}
+
+		var pc openConfig
+		for _, opt := range p.options {
+			if err := opt(&pc); err != nil {
+				return fmt.Errorf("profile %s: %w", p.Name, err)
+			}
+		}
+		c.profile = &pc
+
+		return nil
+	}
+}
+
+// Pragmas are the PRAGMA statements of the profile; e.g. for
+// OpenV2(dbPath, gosqlite.ProfileFast.Pragmas()...).
+func (p Profile) Pragmas() []string {
+	var c openConfig
+	for _, opt := range p.options {
+		opt(&c)
+	}
+
+	return c.pragmas()
+}
+
+// useProfile takes the settings of the profile that are not
+// given as options.
+func (c *openConfig) useProfile() {
+	p := c.profile
+	if p == nil {
+		return
+	}
+
+	c.readOnly = c.readOnly || p.readOnly
+	c.create = c.create || p.create
+	c.uri = c.uri || p.uri
+	c.noGroup = c.noGroup || p.noGroup
+
+	if c.vfs == "" {
+		c.vfs = p.vfs
+	}
+	if c.journalMode == "" {
+		c.journalMode = p.journalMode
+	}
+	if c.synchronous == "" {
+		c.synchronous = p.synchronous
+	}
+	if c.busyTimeout == nil {
+		c.busyTimeout = p.busyTimeout
+	}
+	if c.foreignKeys == nil {
+		c.foreignKeys = p.foreignKeys
+	}
+	if c.cacheSize == nil {
+		c.cacheSize = p.cacheSize
+	}
+	if c.mmapSize == nil {
+		c.mmapSize = p.mmapSize
+	}
+	if c.secureDelete == nil {
+		c.secureDelete = p.secureDelete
+	}
+}
+
+// effectivePragmas are the settings reported by EffectivePragmas().
+var effectivePragmas = []string{
+	"journal_mode",
+	"synchronous",
+	"locking_mode",
+	"busy_timeout",
+	"foreign_keys",
+	"secure_delete",
+	"cache_size",
+	"mmap_size",
+	"temp_store",
+	"query_only",
+}
+
+// EffectivePragmas returns the settings of the writer connection, as
+// read from the database (not as requested at open); e.g.
+// map[journal_mode:wal synchronous:NORMAL foreign_keys:ON ...].
+func (d *DB) EffectivePragmas() (map[string]string, error) {
+	if d.Closed || d.DBHwnd == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	m := make(map[string]string, len(effectivePragmas))
+	for _, name := range effectivePragmas {
+		v, err := d.pragmaValue(name)
+		if err != nil {
+			return nil, err
+		}
+		m[name] = pragmaText(name, v)
+	}
+
+	return m, nil
+}
+
+// pragmaValue reads the value of a PRAGMA of the main database;
+// the caller must hold the lock of the connection.
+func (d *DB) pragmaValue(name string) (string, error) {
+	sqlx := "PRAGMA " + name
+	if name != "busy_timeout" && name != "foreign_keys" && name != "query_only" {
+		sqlx = "PRAGMA main." + name
+	}
+
+	csqlx := C.CString(sqlx)
+	defer C.free(unsafe.Pointer(csqlx))
+
+	var ppStmt *C.sqlite3_stmt
+	rc := C.sqlite3_prepare_v2(d.DBHwnd, csqlx, -1, &ppStmt, nil)
+	if rc != SQLITE_OK {
+		return "", withSQL(getSQLiteErr(rc, d.DBHwnd), sqlx)
+	}
+	defer C.sqlite3_finalize(ppStmt)
+
+	rc = C.sqlite3_step(ppStmt)
+	if rc != SQLITE_ROW {
+		if rc == SQLITE_DONE {
+			return "", nil
+		}
+		return "", withSQL(getSQLiteErr(rc, d.DBHwnd), sqlx)
+	}
+
+	return C.GoString((*C.char)(unsafe.Pointer(C.sqlite3_column_text(ppStmt, 0)))), nil
+}
+
+// pragmaText is the name of a numeric PRAGMA value; e.g.
+// synchronous 1 is NORMAL.
+func pragmaText(name string, v string) string {
+	var names []string
+
+	switch name {
+	case "synchronous":
+		s := SynchronousMode()
+		names = []string{string(s.Off), string(s.Normal), string(s.Full), string(s.Extra)}
+	case "foreign_keys", "query_only":
+		names = []string{"OFF", "ON"}
+	case "secure_delete":
+		names = []string{"OFF", "ON", "FAST"}
+	case "temp_store":
+		names = []string{"DEFAULT", "FILE", "MEMORY"}
+	default:
+		return v
+	}
+
+	for i := range names {
+		if v == fmt.Sprint(i) {
+			return names[i]
+		}
+	}
+
+	return v
+}
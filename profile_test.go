@@ -0,0 +1,105 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		want    map[string]string
+	}{
+		{ProfileDurable, map[string]string{"journal_mode": "wal", "synchronous": "NORMAL"}},
+		{ProfileFast, map[string]string{"journal_mode": "truncate", "synchronous": "OFF", "secure_delete": "ON"}},
+		{ProfileSecure, map[string]string{"journal_mode": "delete", "synchronous": "FULL",
+			"secure_delete": "ON", "foreign_keys": "ON"}},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "test.db")
+		db, err := OpenWith(path, WithCreate(), WithProfile(tt.profile), WithoutGroupTracking())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.profile.Name, err)
+		}
+
+		m, err := db.EffectivePragmas()
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.profile.Name, err)
+		}
+		for k, v := range tt.want {
+			if m[k] != v {
+				t.Fatalf("%s: %s is %q, want %q", tt.profile.Name, k, m[k], v)
+			}
+		}
+	}
+}
+
+func TestProfileReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := OpenWith(path, WithCreate(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db, "create table t(a)")
+	db.Close()
+
+	db, err = OpenWith(path, WithProfile(ProfileReadOnly), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	res := db.Exec("insert into t values(1)")
+	if res.Error() == nil {
+		t.Fatal("no error for a write to a read-only database")
+	}
+}
+
+func TestOpenV2Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := OpenWith(path, WithCreate(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// no PRAGMA is forced; i.e. the defaults of sqlite3 (the
+	// default of secure_delete is a compile-time option)
+	db, err = OpenV2(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBGrp.Remove(db)
+
+	m, err := db.EffectivePragmas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["synchronous"] == "OFF" {
+		t.Fatal("synchronous is OFF")
+	}
+
+	// the former defaults are opt-in
+	pragmas := strings.ToLower(strings.Join(ProfileFast.Pragmas(), "\n"))
+	for _, want := range []string{"journal_mode", "synchronous", "secure_delete"} {
+		if !strings.Contains(pragmas, want) {
+			t.Fatalf("%s is not in %q", want, pragmas)
+		}
+	}
+	db2, err := OpenV2(path, ProfileFast.Pragmas()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBGrp.Remove(db2)
+	if m, err = db2.EffectivePragmas(); err != nil || m["synchronous"] != "OFF" {
+		t.Fatalf("got synchronous %s, %v; want OFF", m["synchronous"], err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+)
+
+// ErrNoRows is returned by QueryOne() when the query has no rows;
+// it is the same as sql.ErrNoRows.
+var ErrNoRows = sql.ErrNoRows
+
+// QueryAll runs a query and returns all of its rows as T. T can be a
+// struct (see Rows.ScanStruct()) or, for a single-column query, a
+// scalar type (int64, string, []byte, time.Time, ...) or a sql.Scanner
+// (e.g. sql.NullString); e.g.
+//
+//	names, err := gosqlite.QueryAll[string](db, "select name from person")
+//	people, err := gosqlite.QueryAll[Person](db, "select * from person where age > ?", 30)
+func QueryAll[T any](db *DB, sqlx string, args ...any) ([]T, error) {
+	var list []T
+
+	for v, err := range QueryIter[T](db, sqlx, args...) {
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+
+	return list, nil
+}
+
+// QueryOne runs a query and returns its first row as T (see QueryAll());
+// ErrNoRows is returned if the query has no rows. e.g.
+//
+//	cnt, err := gosqlite.QueryOne[int64](db, "select count(*) from person")
+func QueryOne[T any](db *DB, sqlx string, args ...any) (T, error) {
+	var zero T
+
+	for v, err := range QueryIter[T](db, sqlx, args...) {
+		// the statement is closed on break
+		return v, err
+	}
+
+	return zero, ErrNoRows
+}
+
+// QueryIter runs a query and yields its rows as T (see QueryAll()).
+// The statement is closed when the iteration ends, e.g. on break
+// or on the first error.
+//
+//	for p, err := range gosqlite.QueryIter[Person](db, "select * from person") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func QueryIter[T any](db *DB, sqlx string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rs, err := db.Query(sqlx, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rs.Close()
+
+		for rs.Next() {
+			var v T
+			if err := scanTyped(rs, &v); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if rs.Err() != nil {
+			yield(zero, rs.Err())
+		}
+	}
+}
+
+// scanTyped scans the current row into dest; a pointer to a
+// struct, to a sql.Scanner or to one of the scalar types.
+func scanTyped(rs *Rows, dest any) error {
+
+	sc, isScanner := dest.(sql.Scanner)
+
+	t := reflect.TypeOf(dest).Elem()
+	if !isScanner && t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+		return rs.ScanStruct(dest)
+	}
+
+	if !isScanner && !isScalarType(t) {
+		return fmt.Errorf("type %s is not supported; expected a struct or a scalar type", t)
+	}
+
+	if rs.colCount != 1 {
+		return fmt.Errorf("expected one column for type %s; the query has %d columns", t, rs.colCount)
+	}
+
+	mu := rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	val := rs.db.getStmtColVal(rs.stmt, 0)
+	if val == nil {
+		if isScanner {
+			return sc.Scan(nil)
+		}
+		// NULL; the zero value of T
+		return nil
+	}
+
+	return assignColVal(val, dest, 0)
+}
+
+// isScalarType reports whether a column value can be
+// converted to t; see assignColVal().
+func isScalarType(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueryAll(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(i integer, s text, b blob, d datetime)")
+
+	d := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	mustExec(t, db, "insert into t values(?, ?, ?, ?)", 1, "a", []byte{1, 2}, d)
+	mustExec(t, db, "insert into t values(?, ?, ?, ?)", 2, "b", []byte{3}, d.Add(time.Hour))
+
+	ints, err := QueryAll[int64](db, "select i from t order by i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ints, []int64{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", ints)
+	}
+
+	strs, err := QueryAll[string](db, "select s from t where i > ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(strs, []string{"b"}) {
+		t.Fatalf("got %v, want [b]", strs)
+	}
+
+	b, err := QueryOne[[]byte](db, "select b from t where i = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", b)
+	}
+
+	tm, err := QueryOne[time.Time](db, "select d from t where i = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Equal(d) {
+		t.Fatalf("got %v, want %v", tm, d)
+	}
+
+	type row struct {
+		I int64
+		S string
+	}
+	rows, err := QueryAll[row](db, "select i, s from t order by i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rows, []row{{1, "a"}, {2, "b"}}) {
+		t.Fatalf("got %v", rows)
+	}
+}
+
+func TestQueryOneErrors(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a, b)")
+
+	if _, err := QueryOne[int64](db, "select a from t"); !errors.Is(err, ErrNoRows) {
+		t.Fatalf("got %v, want ErrNoRows", err)
+	}
+
+	mustExec(t, db, "insert into t values(1, 2)")
+	if _, err := QueryOne[int64](db, "select a, b from t"); err == nil {
+		t.Fatal("no error for two columns")
+	}
+	if _, err := QueryOne[map[string]any](db, "select a from t"); err == nil {
+		t.Fatal("no error for an unsupported type")
+	}
+	if _, err := QueryOne[int64](db, "select nosuchcolumn from t"); err == nil {
+		t.Fatal("no error for a bad query")
+	}
+
+	// NULL is the zero value
+	n, err := QueryOne[int64](db, "select null")
+	if err != nil || n != 0 {
+		t.Fatalf("got %d, %v; want 0", n, err)
+	}
+}
+
+func TestQueryIterBreak(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	for i := range 10 {
+		mustExec(t, db, "insert into t values(?)", i)
+	}
+
+	var got []int64
+	for v, err := range QueryIter[int64](db, "select a from t order by a") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if !slices.Equal(got, []int64{0, 1, 2}) {
+		t.Fatalf("got %v, want [0 1 2]", got)
+	}
+
+	// the statement is closed on break; the table can be dropped
+	mustExec(t, db, "drop table t")
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"iter"
+	"strings"
+)
+
+// All yields the rows one at a time; Rows is positioned on the
+// current row (see Rows.Scan() and Rows.ScanStruct()). Rows is
+// closed when the iteration ends, incl. on break or on error. e.g.
+//
+//	rows, err := db.Query("select id, name from person")
+//	if err != nil {
+//		return err
+//	}
+//	for row, err := range rows.All() {
+//		if err != nil {
+//			return err
+//		}
+//		var id int64
+//		var name string
+//		row.Scan(&id, &name)
+//	}
+func (rs *Rows) All() iter.Seq2[*Rows, error] {
+	return func(yield func(*Rows, error) bool) {
+		defer rs.Close()
+
+		for rs.Next() {
+			if !yield(rs, nil) {
+				return
+			}
+		}
+
+		if rs.Err() != nil {
+			yield(nil, rs.Err())
+		}
+	}
+}
+
+// RowView is the current row of DB.Each(); it is valid
+// only for the duration of the callback.
+type RowView struct {
+	rs *Rows
+}
+
+// Columns returns the column names of the row.
+func (r RowView) Columns() []string {
+	cols, _ := r.rs.Columns()
+	return cols
+}
+
+// Value returns the value of the column at index i; i.e.
+// int64, float64, string, []byte or nil (NULL).
+func (r RowView) Value(i int) any {
+	if i < 0 || i >= r.rs.colCount {
+		return nil
+	}
+
+	mu := r.rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return r.rs.db.getStmtColVal(r.rs.stmt, i)
+}
+
+// Get returns the value of a column by its name (case-insensitive);
+// see Value().
+func (r RowView) Get(colName string) any {
+	cols := r.Columns()
+	for i := range cols {
+		if strings.EqualFold(cols[i], colName) {
+			return r.Value(i)
+		}
+	}
+
+	return nil
+}
+
+// Scan copies the columns of the row into the values
+// pointed to by dest; see Rows.Scan().
+func (r RowView) Scan(dest ...any) error {
+	return r.rs.Scan(dest...)
+}
+
+// ScanStruct copies the columns of the row into the fields
+// of a struct; see Rows.ScanStruct().
+func (r RowView) ScanStruct(dest any) error {
+	return r.rs.ScanStruct(dest)
+}
+
+// Each runs a query and calls fn for every row, without loading
+// the result into a DataTable. It stops at the first error returned
+// by fn, or when ctx is done (see QueryWithContext()); the statement
+// is closed on return. e.g.
+//
+//	err := db.Each(ctx, "select * from person where age > ?", []any{30},
+//		func(row gosqlite.RowView) error {
+//			var p Person
+//			if err := row.ScanStruct(&p); err != nil {
+//				return err
+//			}
+//			...
+//			return nil
+//		})
+func (d *DB) Each(ctx context.Context, sqlx string, args []any, fn func(row RowView) error) error {
+
+	rs, err := d.QueryWithContext(ctx, sqlx, args...)
+	if err != nil {
+		return err
+	}
+
+	for row, err := range rs.All() {
+		if err != nil {
+			return err
+		}
+		if err := fn(RowView{rs: row}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func openNumbers(t *testing.T, n int) *DB {
+	t.Helper()
+
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a integer, b text)")
+	for i := range n {
+		mustExec(t, db, "insert into t values(?, ?)", i, "x")
+	}
+
+	return db
+}
+
+func TestRowsAll(t *testing.T) {
+	db := openNumbers(t, 5)
+
+	rows, err := db.Query("select a, b from t order by a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum int64
+	for row, err := range rows.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a int64
+		var b string
+		if err := row.Scan(&a, &b); err != nil {
+			t.Fatal(err)
+		}
+		sum += a
+		if a == 2 {
+			break
+		}
+	}
+	if sum != 3 {
+		t.Fatalf("got %d, want 3", sum)
+	}
+
+	// closed on break
+	if rows.Next() {
+		t.Fatal("the rows are not closed")
+	}
+	mustExec(t, db, "drop table t")
+}
+
+func TestEach(t *testing.T) {
+	db := openNumbers(t, 5)
+
+	var n int
+	err := db.Each(context.Background(), "select a, b from t where a >= ?", []any{1}, func(row RowView) error {
+		n++
+		if cols := row.Columns(); len(cols) != 2 || cols[0] != "a" {
+			t.Fatalf("got columns %v", cols)
+		}
+		if row.Value(0) != row.Get("A") {
+			t.Fatalf("got %v and %v", row.Value(0), row.Get("A"))
+		}
+		if row.Get("b") != "x" || row.Value(5) != nil || row.Get("none") != nil {
+			t.Fatalf("got %v, %v, %v", row.Get("b"), row.Value(5), row.Get("none"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("got %d rows, want 4", n)
+	}
+
+	// the error of fn stops the iteration
+	stop := errors.New("stop")
+	n = 0
+	err = db.Each(context.Background(), "select a from t", nil, func(row RowView) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("got %v after %d rows, want stop after 1", err, n)
+	}
+
+	if err := db.Each(context.Background(), "select nosuchcolumn from t", nil, func(RowView) error { return nil }); err == nil {
+		t.Fatal("no error for a bad query")
+	}
+
+	// the statement is closed
+	mustExec(t, db, "drop table t")
+}
+
+func TestEachContext(t *testing.T) {
+	db := openNumbers(t, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := db.Each(ctx, "select a from t", nil, func(row RowView) error {
+		n++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Line is the line of the script (starting at 1) where the statement
+// of the result begins; see ExecScript().
+func (r *Result) Line() int {
+	return r.line
+}
+
+// SQL is the statement of the result; see ExecScript().
+func (r *Result) SQL() string {
+	return r.sql
+}
+
+// ExecScript runs the statements of a script one after the other;
+// the statements are split by sqlite3 (see the pzTail of Prepare()),
+// so a trigger (CREATE TRIGGER ... BEGIN ...; END), or a semicolon
+// in a string literal or a comment is not a problem. The positional
+// args are bound in order, each statement takes as many as it has
+// place holders; named args (a map, a struct or sql.Named() values)
+// are bound to every statement, and a map key or a sql.Named() value
+// that no statement uses is an error before any statement runs.
+//
+// It returns a Result per statement, incl. the line where the statement
+// begins. It stops at the first error, which is also in the Result of the
+// failing statement; the statements before it are not rolled back, see
+// ExecScriptTx(). Args left over after the last statement are an error.
+// e.g.
+//
+//	res, err := db.ExecScript(ctx, `
+//		CREATE TABLE person(id INTEGER PRIMARY KEY, name TEXT);
+//		CREATE TRIGGER person_ai AFTER INSERT ON person BEGIN
+//			UPDATE stats SET cnt = cnt + 1;
+//		END;
+//		INSERT INTO person(name) VALUES(?);`, "a;b")
+func (d *DB) ExecScript(ctx context.Context, sqlx string, args ...any) ([]Result, error) {
+	if d == nil || d.Closed {
+		return nil, errors.New("database is not open")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// see ConnPool.MaxConcurrentRequests
+	leave, err := d.enterRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+
+	// other writes wait for the script; see doExec().
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.execScript(ctx, sqlx, args)
+}
+
+// ExecScriptTx runs a script (see ExecScript()) in an Immediate
+// transaction; the transaction is committed if all statements
+// succeed, or else rolled back. See WithTx().
+func (d *DB) ExecScriptTx(ctx context.Context, sqlx string, args ...any) ([]Result, error) {
+	var res []Result
+
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		res, err = tx.ExecScript(sqlx, args...)
+		return err
+	})
+
+	return res, err
+}
+
+// ExecScript runs a script in the transaction; see DB.ExecScript().
+func (tx *Tx) ExecScript(sqlx string, args ...any) ([]Result, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	return tx.db.execScript(tx.ctx, sqlx, args)
+}
+
+// execScript runs the statements of a script on the writer
+// connection; the caller serializes the writes (if needed).
+func (d *DB) execScript(ctx context.Context, sqlx string, args []any) ([]Result, error) {
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if d.Closed {
+		return nil, errors.New("database is not open")
+	}
+	if d.DBHwnd == nil {
+		return nil, errors.New("database no longer available")
+	}
+
+	defer d.requestStarted()()
+
+	// abort the running statement, if ctx is done
+	stop := d.watchContext(ctx)
+	defer stop()
+
+	args = d.prepareFixPlaceholders(args)
+	m, strict, isNamed, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if strict {
+		// every value must be used by (at least) one of the
+		// statements; checked before any statement runs.
+		if err := checkUnused(m, paramNames(sqlx)); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []Result
+	argNo := 0
+
+	// the tail of Prepare() is trimmed; so is the rest of the script
+	end := len(strings.TrimRightFunc(sqlx, unicode.IsSpace))
+	rest := strings.TrimSpace(sqlx)
+
+	for rest != "" {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		var r Result
+		// the line of the statement, after its comments
+		pos := end - len(skipComments(rest))
+		r.line = 1 + strings.Count(sqlx[:pos], "\n")
+
+		var s *Stmt
+		var tail string
+		err := d.retry(ctx, func() error {
+			var err error
+			s, tail, err = d.prepareCached(rest, nil)
+			return err
+		})
+		if err != nil {
+			r.sql = rest
+			return append(results, r.failed(err)), r.lineErr(err)
+		}
+		r.sql = strings.TrimSpace(skipComments(strings.TrimSuffix(rest, tail)))
+		if len(tail) >= len(rest) {
+			// no progress; should not happen
+			d.releaseStmt(s)
+			break
+		}
+		rest = tail
+
+		if s.cStmt == nil {
+			// a comment or an empty statement
+			d.releaseStmt(s)
+			continue
+		}
+
+		// the args of the statement
+		var p []any
+		if isNamed && !strict {
+			// a struct; its fields need not be used
+			p = args
+		} else if isNamed {
+			// the values of the other statements are not
+			// an error; see checkUnused() above.
+			p = []any{s.usedValues(m)}
+		} else if n := int(C.sqlite3_bind_parameter_count(s.cStmt)); n > 0 {
+			// the place holders without a value are NULL
+			p = make([]any, n)
+			copy(p, args[min(argNo, len(args)):])
+			argNo += n
+		}
+		if !isNamed && skipComments(rest) == "" && argNo < len(args) && !allNil(args[argNo:]) {
+			// the last statement; it is not run with args left over
+			d.releaseStmt(s)
+			err = fmt.Errorf("%d args given, %d used by the script", len(args), argNo)
+			return append(results, r.failed(err)), err
+		}
+		if len(p) > 0 {
+			if err = s.bind(p); err != nil {
+				d.releaseStmt(s)
+				return append(results, r.failed(err)), r.lineErr(err)
+			}
+		}
+
+		// retry the statement, if the database is locked
+		total := C.sqlite3_total_changes64(d.DBHwnd)
+		err = d.retry(ctx, func() error {
+			rc := C.sqlite3_step(s.cStmt)
+			for rc == SQLITE_ROW {
+				// the rows of a query are discarded
+				rc = C.sqlite3_step(s.cStmt)
+			}
+			if rc != SQLITE_DONE {
+				C.sqlite3_reset(s.cStmt)
+				return d.stepErr(ctx, rc, r.sql)
+			}
+			return nil
+		})
+		if err == nil {
+			// sqlite3_changes() is of the last INSERT, UPDATE or DELETE;
+			// i.e. not of this statement, unless it changed rows.
+			if C.sqlite3_total_changes64(d.DBHwnd) != total {
+				r.rowsAffected = int64(C.sqlite3_changes(d.DBHwnd))
+			}
+			r.lastInsertId = int64(C.sqlite3_last_insert_rowid(d.DBHwnd))
+		}
+		d.releaseStmt(s)
+
+		// the changes of a commit of the script are sent
+		// when the lock is released; see connMutex.
+		d.hooks.seal()
+
+		if err != nil {
+			return append(results, r.failed(err)), r.lineErr(err)
+		}
+
+		d.purgeStmtCacheOnSchemaChange(r.sql)
+		d.checkJournalMode(r.sql)
+
+		results = append(results, r)
+	}
+
+	if !isNamed && argNo < len(args) && !allNil(args[argNo:]) {
+		// nil args are no args; see bind()
+		return results, fmt.Errorf("%d args given, %d used by the script", len(args), argNo)
+	}
+
+	return results, nil
+}
+
+// allNil reports whether all args are nil.
+func allNil(args []any) bool {
+	for _, a := range args {
+		if a != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// failed sets the error of the result of a statement.
+func (r *Result) failed(err error) Result {
+	r.rowsAffected = -1
+	r.err = err
+	return *r
+}
+
+// lineErr is the error of a statement of a script, incl.
+// its line number.
+func (r *Result) lineErr(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return fmt.Errorf("line %d: %w", r.line, err)
+}
+
+// skipComments removes the leading white-space and
+// comments of an SQL statement.
+func skipComments(sqlx string) string {
+	for {
+		sqlx = strings.TrimLeftFunc(sqlx, unicode.IsSpace)
+
+		switch {
+		case strings.HasPrefix(sqlx, "--"):
+			i := strings.IndexByte(sqlx, '\n')
+			if i < 0 {
+				return ""
+			}
+			sqlx = sqlx[i+1:]
+		case strings.HasPrefix(sqlx, "/*"):
+			i := strings.Index(sqlx[2:], "*/")
+			if i < 0 {
+				return ""
+			}
+			sqlx = sqlx[i+4:]
+		default:
+			return sqlx
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	db := openTestDB(t, false)
+
+	res, err := db.ExecScript(context.Background(), `
+		create table t(a, b);
+		insert into t values(?, ?);
+		-- a comment
+		insert into t values(?, ?);`, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("got %d results, want 3", len(res))
+	}
+	if n := count(t, db, "select sum(a + b) from t"); n != 10 {
+		t.Fatalf("got %d, want 10", n)
+	}
+
+	if _, err := db.ExecScript(context.Background(), "insert into t values(?, ?)", 1, 2, 3); err == nil {
+		t.Fatal("no error for the args left over")
+	}
+}
+
+func TestExecScriptNullPlaceHolders(t *testing.T) {
+	db := openTestDB(t, false)
+
+	// the place holders without a value are NULL
+	_, err := db.ExecScript(context.Background(), `
+		create table t(a, b);
+		insert into t values(?, ?);
+		insert into t values(?, ?);`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, "select count(*) from t where b is null"); n != 2 {
+		t.Fatalf("got %d, want 2", n)
+	}
+	if n := count(t, db, "select count(*) from t where a = 1"); n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+}
+
+func TestExecScriptStmtCache(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	before := db.StmtCacheStats().Size
+	for i := range 5 {
+		_, err := db.ExecScript(context.Background(), "insert into t values(?); insert into t values(?);", i, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the last statement of the script is cached (under
+	// its own text); not the script.
+	if n := db.StmtCacheStats().Size - before; n > 1 {
+		t.Fatalf("%d statements cached, want at most 1", n)
+	}
+}
+
+func TestExecScriptEvents(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	ch, err := db.Subscribe("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.ExecScript(context.Background(), `
+		begin; insert into t values(1); commit;
+		begin; insert into t values(2); rollback;
+		begin; insert into t values(3); insert into t values(4); commit;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one event for each committed transaction
+	for _, want := range []int{1, 2} {
+		e, ok := nextEvent(ch)
+		if !ok {
+			t.Fatal("no event")
+		}
+		if len(e.Changes) != want {
+			t.Fatalf("got %d changes, want %d", len(e.Changes), want)
+		}
+	}
+	if e, ok := nextEvent(ch); ok {
+		t.Fatalf("unexpected event: %v", e)
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdio.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DriverName is the name the package registers itself
+// with database/sql; i.e.
+//
+//	db, err := sql.Open("gosqlite", "/path/to/my.sqlite")
+const DriverName = "gosqlite"
+
+func init() {
+	sql.Register(DriverName, &SQLiteDriver{})
+}
+
+// SQLiteDriver implements driver.Driver and driver.DriverContext
+// on top of DB. Every database/sql connection is a DB instance
+// of its own.
+type SQLiteDriver struct{}
+
+// Open opens a new connection to a database file. The file is
+// created (with no built-in tables) if it does not exist.
+// ":memory:" (or an empty name) opens an in-memory database.
+func (drv *SQLiteDriver) Open(name string) (driver.Conn, error) {
+	cn, err := drv.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return cn.Connect(context.Background())
+}
+
+// OpenConnector returns a driver.Connector for a database file.
+func (drv *SQLiteDriver) OpenConnector(name string) (driver.Connector, error) {
+	return &connector{drv: drv, dbFilePath: name}, nil
+}
+
+// NewConnector returns a driver.Connector that can be passed to
+// sql.OpenDB(). pragma is a list of PRAGMA commands to be applied
+// to every new connection; e.g.
+//
+//	db := sql.OpenDB(gosqlite.NewConnector("my.sqlite", "PRAGMA main.journal_mode = WAL"))
+func NewConnector(dbFilePath string, pragma ...string) driver.Connector {
+	return &connector{drv: &SQLiteDriver{}, dbFilePath: dbFilePath, pragma: pragma}
+}
+
+type connector struct {
+	drv        *SQLiteDriver
+	dbFilePath string
+	pragma     []string
+}
+
+func (cn *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var d *DB
+	var err error
+
+	// the connection belongs to database/sql; so it is not
+	// added to DBGrp (which would ping, optimize and vacuum it).
+	if cn.dbFilePath == "" || cn.dbFilePath == ":memory:" {
+		d, err = OpenMemory()
+	} else {
+		d, err = OpenWith(cn.dbFilePath, WithCreate(), WithoutGroupTracking())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cn.pragma {
+		if _, err = d.Execute(cn.pragma[i]); err != nil {
+			d.Close()
+			return nil, err
+		}
+	}
+
+	return &conn{db: d}, nil
+}
+
+func (cn *connector) Driver() driver.Driver {
+	return cn.drv
+}
+
+// conn is a single database/sql connection; database/sql
+// makes sure that it is used by one goroutine at a time.
+type conn struct {
+	db *DB
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ps, tail, err := c.db.Prepare(query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(skipComments(tail), "; \t\r\n") != "" {
+		// the rest would not be run by the stmt
+		ps.Close()
+		return nil, fmt.Errorf("a prepared statement must be a single sql statement; found: %s", tail)
+	}
+
+	paramCnt := int(C.sqlite3_bind_parameter_count(ps.cStmt))
+	names := make([]string, paramCnt)
+	for i := range paramCnt {
+		p := C.sqlite3_bind_parameter_name(ps.cStmt, C.int(i+1))
+		if p != nil {
+			names[i] = C.GoString(p)
+		}
+	}
+
+	return &stmt{c: c, ps: &ps, names: names}, nil
+}
+
+func (c *conn) Close() error {
+	if c.db == nil || c.db.Closed {
+		return nil
+	}
+
+	return c.db.Close()
+}
+
+func (c *conn) IsValid() bool {
+	return c.db != nil && !c.db.Closed
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if c.db == nil || c.db.Ping() != 0 {
+		return driver.ErrBadConn
+	}
+
+	return ctx.Err()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction using a savepoint (see TxBegin()).
+// A read-only transaction sets PRAGMA query_only for its lifetime.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelSerializable:
+	default:
+		return nil, fmt.Errorf("isolation level %s is not supported", sql.IsolationLevel(opts.Isolation))
+	}
+
+	if opts.ReadOnly {
+		if _, err := c.db.Execute("PRAGMA query_only = 1"); err != nil {
+			return nil, err
+		}
+	}
+
+	txID, err := c.db.TxBegin()
+	if err != nil {
+		if opts.ReadOnly {
+			c.db.Execute("PRAGMA query_only = 0")
+		}
+		return nil, err
+	}
+
+	return &tx{c: c, txID: txID, readOnly: opts.ReadOnly}, nil
+}
+
+// ExecContext executes a query without preparing it first.
+// Named values are left to the prepared statement (see stmt).
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	vals, ok := positionalValues(args)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	res := c.db.ExecWithContext(ctx, query, vals...)
+	if res.Error() != nil {
+		return nil, res.Error()
+	}
+
+	return &res, nil
+}
+
+// QueryContext runs a query without preparing it first.
+// Named values are left to the prepared statement (see stmt).
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	vals, ok := positionalValues(args)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	return c.query(ctx, query, vals)
+}
+
+func (c *conn) query(ctx context.Context, query string, vals []any) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rs, err := c.db.QueryWithContext(ctx, query, vals...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{rs: rs}, nil
+}
+
+// positionalValues returns the values of args by their ordinal
+// positions; ok is false if any of the args is named.
+func positionalValues(args []driver.NamedValue) (vals []any, ok bool) {
+	vals = make([]any, len(args))
+	for i := range args {
+		if args[i].Name != "" {
+			return nil, false
+		}
+		vals[args[i].Ordinal-1] = args[i].Value
+	}
+
+	return vals, true
+}
+
+// stmt is compiled once by PrepareContext() and re-used until it
+// is closed; each Exec/Query binds the args to the same Stmt (Bind()
+// resets it first), and Close() finalizes it.
+type stmt struct {
+	c  *conn
+	ps *Stmt
+
+	// names are the parameter names by their index;
+	// i.e. ":id", "@id", "$id", "?2" or "" for "?".
+	names []string
+}
+
+func (s *stmt) Close() error {
+	return s.ps.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return len(s.names)
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.bindValues(ctx, args); err != nil {
+		return nil, err
+	}
+
+	res := s.ps.doExec(ctx)
+	if res.Error() != nil {
+		return nil, res.Error()
+	}
+
+	return &res, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.bindValues(ctx, args); err != nil {
+		return nil, err
+	}
+
+	rs, err := s.ps.Query()
+	if err != nil {
+		return nil, err
+	}
+	rs.ctx = ctx
+
+	return &rows{rs: rs}, nil
+}
+
+// bindValues places args in the position of their parameters
+// and binds them to the statement; a named value is matched to
+// its parameter regardless of its prefix (:name, @name or $name).
+func (s *stmt) bindValues(ctx context.Context, args []driver.NamedValue) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	vals := make([]any, len(s.names))
+
+	for i := range args {
+		indx := args[i].Ordinal - 1
+		if args[i].Name != "" {
+			indx = -1
+			for j := range s.names {
+				if len(s.names[j]) > 1 && s.names[j][1:] == args[i].Name {
+					indx = j
+					break
+				}
+			}
+			if indx < 0 {
+				return fmt.Errorf("parameter %q does not exist in the sql statement", args[i].Name)
+			}
+		}
+		if indx < 0 || indx >= len(vals) {
+			return fmt.Errorf("parameter %d is out of range; the sql statement has %d parameter(s)", args[i].Ordinal, len(vals))
+		}
+		vals[indx] = args[i].Value
+	}
+
+	return s.ps.Bind(vals...)
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+
+	return nv
+}
+
+type tx struct {
+	c        *conn
+	txID     string
+	readOnly bool
+}
+
+func (t *tx) Commit() error {
+	err := t.c.db.TxCommit(t.txID)
+	t.end()
+
+	return err
+}
+
+func (t *tx) Rollback() error {
+	err := t.c.db.TxRollback(t.txID)
+	t.end()
+
+	return err
+}
+
+func (t *tx) end() {
+	if t.readOnly {
+		t.c.db.Execute("PRAGMA query_only = 0")
+	}
+}
+
+// rows adapts Rows to driver.Rows.
+type rows struct {
+	rs *Rows
+}
+
+func (r *rows) Columns() []string {
+	cols, _ := r.rs.Columns()
+	return cols
+}
+
+func (r *rows) Close() error {
+	return r.rs.Close()
+}
+
+// ColumnTypeDatabaseTypeName returns the declared type of a
+// column (upper-case); empty for an expression.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	cts, err := r.rs.ColumnTypes()
+	if err != nil || index >= len(cts) {
+		return ""
+	}
+
+	return strings.ToUpper(cts[index].DeclType)
+}
+
+// ColumnTypeNullable reports whether a column of a table can be
+// NULL; ok is false for an expression.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	cts, err := r.rs.ColumnTypes()
+	if err != nil || index >= len(cts) || cts[index].TableName == "" {
+		return false, false
+	}
+
+	return !cts[index].NotNULL, true
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.rs.Next() {
+		if r.rs.Err() != nil {
+			return r.rs.Err()
+		}
+		return io.EOF
+	}
+
+	if len(dest) > r.rs.colCount {
+		return errors.New("column/value mismatch")
+	}
+
+	for i := range dest {
+		dest[i] = r.rs.db.getStmtColVal(r.rs.stmt, i)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// openTestSQLDB opens a new database via database/sql.
+func openTestSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open(DriverName, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("create table t(id integer primary key, name text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestDriverPrepare(t *testing.T) {
+	db := openTestSQLDB(t)
+
+	s, err := db.Prepare("insert into t(id, name) values(?, :name)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		res, err := s.Exec(i, sql.Named("name", "n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, _ := res.LastInsertId(); id != int64(i) {
+			t.Fatalf("got id %d, want %d", id, i)
+		}
+	}
+	s.Close()
+
+	q, err := db.Prepare("select count(*) from t where id >= ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for i := range 5 {
+		var n int
+		if err := q.QueryRow(i).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 5-i {
+			t.Fatalf("got %d, want %d", n, 5-i)
+		}
+	}
+
+	if _, err := db.Prepare("select 1; select 2"); err == nil {
+		t.Fatal("no error for a prepared statement of two statements")
+	}
+}
+
+func TestDriverTx(t *testing.T) {
+	db := openTestSQLDB(t)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("insert into t(name) values('a')")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("insert into t(name) values('b')")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	if err := db.QueryRow("select group_concat(name) from t").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "b" {
+		t.Fatalf("got %q, want \"b\"", name)
+	}
+}
+
+func TestDriverScan(t *testing.T) {
+	db := openTestSQLDB(t)
+
+	if _, err := db.Exec("insert into t values(1, 'a'); insert into t values(2, null)"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("select id, name from t order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []sql.NullString
+	for rows.Next() {
+		var id int
+		var name sql.NullString
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].String != "a" || got[1].Valid {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestDriverConnNotInGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if DBGrp.Exists(path) {
+		t.Fatal("a connection of database/sql is in DBGrp")
+	}
+}
+
+func TestDBClose(t *testing.T) {
+	db, err := OpenWith(filepath.Join(t.TempDir(), "test.db"), WithCreate(), WithoutGroupTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !db.Closed || db.DBHwnd != nil {
+		t.Fatal("the database is not marked as closed")
+	}
+	if res := db.Exec("select 1"); res.Error() == nil {
+		t.Fatal("a closed database runs statements")
+	}
+	if err := db.Close(); err == nil {
+		t.Fatal("no error for closing a closed database")
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdio.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"context"
+	"errors"
+)
+
+// A Stmt returned by Prepare() can be re-used; the sql statement is
+// compiled once and bound/executed as many times as needed. e.g.
+//
+//	s, _, err := db.Prepare("insert into t(a, b) values(?, ?)", nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//
+//	for i := range rows {
+//		s.Bind(rows[i].A, rows[i].B)
+//		if res := s.Exec(); res.Error() != nil {
+//			return res.Error()
+//		}
+//	}
+//
+// See: https://www.sqlite.org/c3ref/stmt.html
+
+// Bind resets the statement, clears its current values
+// and binds args to its place holders.
+func (s *Stmt) Bind(args ...any) error {
+	if s.isClosed() {
+		return errors.New("statement is already closed")
+	}
+
+	C.sqlite3_reset(s.cStmt)
+	C.sqlite3_clear_bindings(s.cStmt)
+
+	return s.bind(args)
+}
+
+// Exec runs the statement with its current values and resets
+// it, so that it can be executed again. It waits for an open Tx
+// (see BeginTx()), unless it was prepared by the Tx.
+func (s *Stmt) Exec() Result {
+	return s.doExec(context.Background())
+}
+
+// doExec runs the statement after the other writes, as
+// DB.doExec() does; in its Tx, if it was prepared by one.
+func (s *Stmt) doExec(ctx context.Context) Result {
+	var res Result
+	res.rowsAffected = -1
+
+	if s.isClosed() {
+		res.err = errors.New("statement is already closed")
+		return res
+	}
+
+	if s.tx != nil {
+		if err := s.tx.check(); err != nil {
+			res.err = err
+			return res
+		}
+		if ctx.Done() == nil {
+			ctx = s.tx.ctx
+		}
+		return s.execDo(ctx)
+	}
+
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	return s.execDo(ctx)
+}
+
+// execDo runs the statement; it is aborted when ctx is done.
+// The caller serializes the writes (if needed).
+func (s *Stmt) execDo(ctx context.Context) Result {
+	var res Result
+	res.rowsAffected = -1
+
+	if s.isClosed() {
+		res.err = errors.New("statement is already closed")
+		return res
+	}
+
+	mu := s.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		// e.g. the Tx of the statement is rolled back
+		res.err = err
+		return res
+	}
+
+	stop := s.db.watchContext(ctx)
+	defer stop()
+
+	// retry, if the database is locked
+	res.err = s.db.retry(ctx, func() error {
+		defer C.sqlite3_reset(s.cStmt)
+
+		rc := C.sqlite3_step(s.cStmt)
+		for rc == SQLITE_ROW {
+			// e.g. INSERT ... RETURNING
+			rc = C.sqlite3_step(s.cStmt)
+		}
+
+		if rc != SQLITE_DONE {
+			return s.db.stepErr(ctx, rc, s.SQL)
+		}
+		res.rowsAffected = int64(C.sqlite3_changes(s.db.DBHwnd))
+		res.lastInsertId = int64(C.sqlite3_last_insert_rowid(s.db.DBHwnd))
+
+		return nil
+	})
+
+	return res
+}
+
+// Query runs the statement with its current values and returns
+// Rows to iterate. Closing the Rows resets the statement; it does
+// not release it. Each step of the Rows waits for an open Tx (see
+// BeginTx()), unless the statement was prepared by the Tx.
+func (s *Stmt) Query() (*Rows, error) {
+	if s.isClosed() {
+		return nil, errors.New("statement is already closed")
+	}
+	if s.tx != nil {
+		if err := s.tx.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	C.sqlite3_reset(s.cStmt)
+
+	rows := Rows{
+		stmt:     s,
+		db:       s.db,
+		colCount: int(C.sqlite3_column_count(s.cStmt)),
+		borrowed: true,
+	}
+	if s.tx == nil {
+		rows.txLock = &s.db.mutex
+	}
+	rows.intfc = &rows
+
+	return &rows, nil
+}
+
+// Reset rewinds the statement so that it can be executed
+// again; the values bound to it are kept.
+func (s *Stmt) Reset() error {
+	if s.isClosed() {
+		return errors.New("statement is already closed")
+	}
+
+	C.sqlite3_reset(s.cStmt)
+
+	return nil
+}
+
+// ClearBindings sets all place holders of the statement to NULL.
+func (s *Stmt) ClearBindings() error {
+	if s.isClosed() {
+		return errors.New("statement is already closed")
+	}
+
+	rc := C.sqlite3_clear_bindings(s.cStmt)
+
+	return getSQLiteErr(rc, s.db.DBHwnd)
+}
+
+// Close releases the statement.
+func (s *Stmt) Close() error {
+	if s == nil || s.cStmt == nil || s.released {
+		return nil
+	}
+
+	rc := C.sqlite3_finalize(s.cStmt)
+	s.released = true
+
+	if rc != SQLITE_OK {
+		return getSQLiteErr(rc, s.db.DBHwnd)
+	}
+
+	return nil
+}
+
+func (s *Stmt) isClosed() bool {
+	return s == nil || s.cStmt == nil || s.released || s.db == nil
+}
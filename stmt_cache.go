@@ -0,0 +1,346 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdio.h>
+//#include <stdlib.h>
+//#include "sqlite3.h"
+import "C"
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// defaultStmtCacheSize is the number of compiled statements
+// kept per database; see SetStmtCacheSize().
+const defaultStmtCacheSize = 64
+
+// StmtCacheStats describes the prepared-statement cache of a database.
+type StmtCacheStats struct {
+	Hits     uint64
+	Misses   uint64
+	Size     int // statements currently cached
+	Capacity int
+}
+
+// stmtCache is a size-limited LRU cache of compiled statements
+// keyed by their sql text. A statement is handed out to one caller
+// at a time; a concurrent caller with the same sql text gets a
+// statement of its own (uncached).
+type stmtCache struct {
+	mutex    sync.Mutex
+	capacity int
+	lru      *list.List // front is the most recently used
+	items    map[string]*list.Element
+	hits     uint64
+	misses   uint64
+
+	// tables are the columns of the tables of main (see
+	// GetTableColumns()) by their lower-case name; as of
+	// tablesGen. See tableColumns().
+	tables    map[string][]Column
+	tablesGen uint64
+}
+
+type stmtCacheItem struct {
+	sqlx   string
+	pzTail string
+	stmt   *Stmt
+	inUse  bool
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get returns an idle statement for sqlx, if cached,
+// and marks it as in-use.
+func (c *stmtCache) get(sqlx string) (*Stmt, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[sqlx]
+	if !ok || e.Value.(*stmtCacheItem).inUse {
+		c.misses++
+		return nil, "", false
+	}
+
+	c.hits++
+	item := e.Value.(*stmtCacheItem)
+	item.inUse = true
+	c.lru.MoveToFront(e)
+
+	return item.stmt, item.pzTail, true
+}
+
+// put adds a newly compiled (and in-use) statement to the cache;
+// it evicts the least recently used idle statements, if full.
+func (c *stmtCache) put(sqlx string, pzTail string, s *Stmt) {
+	if c == nil || s == nil || s.cStmt == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.capacity < 1 {
+		return
+	}
+	if _, ok := c.items[sqlx]; ok {
+		// the cached one is being used by another caller
+		return
+	}
+
+	s.cached = true
+	c.items[sqlx] = c.lru.PushFront(&stmtCacheItem{sqlx: sqlx, pzTail: pzTail, stmt: s, inUse: true})
+
+	c.evict()
+}
+
+// evict finalizes idle statements from the back of the
+// list until the cache is within its capacity.
+func (c *stmtCache) evict() {
+	for e := c.lru.Back(); e != nil && c.lru.Len() > c.capacity; {
+		prev := e.Prev()
+		item := e.Value.(*stmtCacheItem)
+		if !item.inUse {
+			c.remove(e)
+			item.stmt.finalize()
+		}
+		e = prev
+	}
+}
+
+func (c *stmtCache) remove(e *list.Element) {
+	item := e.Value.(*stmtCacheItem)
+	item.stmt.cached = false
+	c.lru.Remove(e)
+	delete(c.items, item.sqlx)
+}
+
+// release hands a statement back to the cache. It returns false
+// if the statement is no longer cached; the caller must then
+// finalize it.
+func (c *stmtCache) release(s *Stmt) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[s.SQL]
+	if !ok || e.Value.(*stmtCacheItem).stmt != s {
+		return false
+	}
+
+	C.sqlite3_reset(s.cStmt)
+	C.sqlite3_clear_bindings(s.cStmt)
+	e.Value.(*stmtCacheItem).inUse = false
+
+	c.evict()
+
+	return true
+}
+
+// purge finalizes the idle statements and drops the in-use ones
+// from the cache (they are finalized when released). It is called
+// after the schema of the database changes.
+func (c *stmtCache) purge() {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.tables = nil
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		item := e.Value.(*stmtCacheItem)
+		c.remove(e)
+		if !item.inUse {
+			item.stmt.finalize()
+		}
+		e = next
+	}
+}
+
+// clear finalizes all statements, including the ones that are
+// in-use; it must be called before the database is closed.
+func (c *stmtCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.tables = nil
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		item := e.Value.(*stmtCacheItem)
+		c.remove(e)
+		item.stmt.finalize()
+		e = next
+	}
+}
+
+// finalize releases a statement that is not used by a caller.
+func (s *Stmt) finalize() {
+	if s == nil || s.cStmt == nil || s.released {
+		return
+	}
+
+	C.sqlite3_finalize(s.cStmt)
+	s.released = true
+}
+
+// prepareCached returns a compiled statement from the statement cache
+// (or compiles and caches it) and binds placeHolders to it. The
+// statement must be handed back via releaseStmt(). Only a single
+// statement is cached; the first statement of a script (with more
+// statements in its tail) is not, since the key would be the script.
+func (d *DB) prepareCached(sqlx string, placeHolders []any) (*Stmt, string, error) {
+
+	s, pzTail, ok := d.stmtCache.get(sqlx)
+	if !ok {
+		ps, tail, err := d.Prepare(sqlx, nil)
+		if err != nil {
+			return nil, tail, err
+		}
+		s = &ps
+		pzTail = tail
+		if strings.Trim(skipComments(tail), "; \t\r\n") == "" {
+			d.stmtCache.put(sqlx, pzTail, s)
+		}
+	}
+
+	if err := s.bind(placeHolders); err != nil {
+		d.releaseStmt(s)
+		return nil, pzTail, err
+	}
+
+	return s, pzTail, nil
+}
+
+// releaseStmt hands a statement obtained via prepareCached() back to
+// the cache, or finalizes it if it is not cached.
+func (d *DB) releaseStmt(s *Stmt) {
+	if s == nil || s.released {
+		return
+	}
+
+	if !s.cached || !d.stmtCache.release(s) {
+		s.finalize()
+	}
+}
+
+// purgeStmtCacheOnSchemaChange purges the statement cache if sqlx
+// changes the schema of the database.
+func (d *DB) purgeStmtCacheOnSchemaChange(sqlx string) {
+	sqlx = strings.ToUpper(strings.TrimSpace(sqlx))
+
+	for _, prefix := range []string{"CREATE ", "DROP ", "ALTER ", "VACUUM", "REINDEX", "ATTACH ", "DETACH "} {
+		if strings.HasPrefix(sqlx, prefix) {
+			d.stmtCache.purge()
+			if d.counters != nil {
+				// the readers read the tables again
+				d.counters.schemaGen.Add(1)
+			}
+			return
+		}
+	}
+}
+
+// tableColumns returns GetTableColumns() of a table of main; it is
+// cached until the schema is changed via the DB (or any connection
+// of its pool), and not cached if the statement cache is off.
+func (d *DB) tableColumns(table string) []Column {
+	c := d.stmtCache
+	if c == nil || d.counters == nil {
+		return d.GetTableColumns(table)
+	}
+
+	key := strings.ToLower(table)
+	gen := d.counters.schemaGen.Load()
+
+	c.mutex.Lock()
+	cols, ok := c.tables[key]
+	if c.tablesGen != gen {
+		ok = false
+	}
+	c.mutex.Unlock()
+
+	if ok {
+		return cols
+	}
+
+	cols = d.GetTableColumns(table)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.capacity < 1 {
+		return cols
+	}
+	if c.tables == nil || c.tablesGen != gen {
+		c.tables = make(map[string][]Column)
+		c.tablesGen = gen
+	}
+	c.tables[key] = cols
+
+	return cols
+}
+
+// SetStmtCacheSize sets the number of compiled statements kept by
+// the database for Exec(), Query(), ExecuteNonQuery() and GetDataTable().
+// Zero (or less) turns off the cache.
+func (d *DB) SetStmtCacheSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+
+	if d.stmtCache == nil {
+		d.stmtCache = newStmtCache(size)
+		return
+	}
+
+	d.stmtCache.mutex.Lock()
+	d.stmtCache.capacity = size
+	d.stmtCache.evict()
+	d.stmtCache.mutex.Unlock()
+}
+
+// StmtCacheStats returns the hit/miss counters and the
+// size of the prepared-statement cache.
+func (d *DB) StmtCacheStats() StmtCacheStats {
+	c := d.stmtCache
+	if c == nil {
+		return StmtCacheStats{}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return StmtCacheStats{
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Size:     c.lru.Len(),
+		Capacity: c.capacity,
+	}
+}
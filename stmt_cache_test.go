@@ -0,0 +1,118 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	before := db.StmtCacheStats()
+	for i := range 3 {
+		mustExec(t, db, "insert into t values(?)", i)
+		if _, err := db.ExecuteNonQuery("update t set a = a where a = ?", i); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetDataTable("select a from t where a = ?", i); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := db.Query("select a from t where a > ?", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+
+	st := db.StmtCacheStats()
+	if misses := st.Misses - before.Misses; misses != 4 {
+		t.Fatalf("got %d misses, want 4", misses)
+	}
+	if hits := st.Hits - before.Hits; hits != 8 {
+		t.Fatalf("got %d hits, want 8", hits)
+	}
+	if st.Size != before.Size+4 {
+		t.Fatalf("got size %d, want %d", st.Size, before.Size+4)
+	}
+}
+
+func TestStmtCacheSize(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	db.SetStmtCacheSize(2)
+	mustExec(t, db, "insert into t values(1)")
+	mustExec(t, db, "insert into t values(2)")
+	mustExec(t, db, "insert into t values(3)")
+
+	st := db.StmtCacheStats()
+	if st.Size != 2 || st.Capacity != 2 {
+		t.Fatalf("got size %d, capacity %d; want 2, 2", st.Size, st.Capacity)
+	}
+
+	// the least recently used one is evicted
+	misses := st.Misses
+	mustExec(t, db, "insert into t values(1)")
+	if db.StmtCacheStats().Misses != misses+1 {
+		t.Fatal("the least recently used statement is cached")
+	}
+	mustExec(t, db, "insert into t values(3)")
+	if db.StmtCacheStats().Misses != misses+1 {
+		t.Fatal("a recently used statement is not cached")
+	}
+
+	db.SetStmtCacheSize(0)
+	mustExec(t, db, "insert into t values(3)")
+	if st := db.StmtCacheStats(); st.Size != 0 {
+		t.Fatalf("got size %d, want 0", st.Size)
+	}
+	if n := count(t, db, "select count(*) from t"); n != 6 {
+		t.Fatalf("got %d rows, want 6", n)
+	}
+}
+
+func TestStmtCacheSchemaChange(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	mustExec(t, db, "insert into t values(1)")
+
+	dt, err := db.GetDataTable("select * from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt.Columns) != 1 {
+		t.Fatalf("got %d columns, want 1", len(dt.Columns))
+	}
+
+	// the cached statements are dropped
+	mustExec(t, db, "alter table t add column b")
+	if st := db.StmtCacheStats(); st.Size != 0 {
+		t.Fatalf("got size %d after a schema change, want 0", st.Size)
+	}
+
+	dt, err = db.GetDataTable("select * from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dt.Columns) != 2 {
+		t.Fatalf("got %d columns, want 2", len(dt.Columns))
+	}
+}
+
+func TestStmtCacheClose(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	mustExec(t, db, "insert into t values(1)")
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if st := db.StmtCacheStats(); st.Size != 0 {
+		t.Fatalf("got size %d after Close, want 0", st.Size)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"testing"
+)
+
+func TestStmtExec(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a, b)")
+
+	s, _, err := db.Prepare("insert into t(a, b) values(?, ?)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.SQL != "insert into t(a, b) values(?, ?)" {
+		t.Fatalf("got SQL %q", s.SQL)
+	}
+
+	for i := range 3 {
+		if err := s.Bind(i, i*10); err != nil {
+			t.Fatal(err)
+		}
+		if res := s.Exec(); res.Error() != nil {
+			t.Fatal(res.Error())
+		}
+	}
+
+	// the values are kept by Reset(), and set to NULL by ClearBindings()
+	s.Reset()
+	if res := s.Exec(); res.Error() != nil {
+		t.Fatal(res.Error())
+	}
+	s.ClearBindings()
+	if res := s.Exec(); res.Error() != nil {
+		t.Fatal(res.Error())
+	}
+
+	if n := count(t, db, "select count(*) from t where a = 2 and b = 20"); n != 2 {
+		t.Fatalf("got %d rows of the reset statement, want 2", n)
+	}
+	if n := count(t, db, "select count(*) from t where a is null"); n != 1 {
+		t.Fatalf("got %d rows of NULLs, want 1", n)
+	}
+
+	s.Close()
+	if res := s.Exec(); res.Error() == nil {
+		t.Fatal("a closed statement was executed")
+	}
+}
+
+func TestStmtQuery(t *testing.T) {
+	db := openTestDB(t, false)
+
+	s, _, err := db.Prepare("select ? + 1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := range 3 {
+		s.Bind(i)
+
+		rs, err := s.Query()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.Next() {
+			t.Fatalf("no row: %v", rs.Err())
+		}
+		var n int
+		if err := rs.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		// the statement is reset, not released
+		rs.Close()
+
+		if n != i+1 {
+			t.Fatalf("got %d, want %d", n, i+1)
+		}
+	}
+}
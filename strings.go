@@ -5,29 +5,49 @@
 
 package gosqlite
 
-var j JMode
+// JMode is a journal mode of a database; see JounalMode() and
+// https://www.sqlite.org/pragma.html#pragma_journal_mode
+type JMode string
 
-type JMode struct {
-	Delete   string
-	Truncate string
-	Off      string
-	Persist  string
-	Memory   string
-	Wal      string
+type JournalModes struct {
+	Delete   JMode
+	Truncate JMode
+	Off      JMode
+	Persist  JMode
+	Memory   JMode
+	Wal      JMode
 }
 
-func JounalMode() JMode {
-	j.Delete = "DELETE"
-	j.Truncate = "TRUNCATE"
-	j.Off = "OFF"
-	j.Memory = "MEMORY"
-	j.Persist = "PERSIST"
-	j.Wal = "WAL"
+func JounalMode() JournalModes {
+	return JournalModes{
+		Delete:   "DELETE",
+		Truncate: "TRUNCATE",
+		Off:      "OFF",
+		Memory:   "MEMORY",
+		Persist:  "PERSIST",
+		Wal:      "WAL",
+	}
+}
+
+// SyncMode is a value of PRAGMA synchronous; see SynchronousMode() and
+// https://www.sqlite.org/pragma.html#pragma_synchronous
+type SyncMode string
 
-	return j
+type SyncModes struct {
+	Off    SyncMode
+	Normal SyncMode
+	Full   SyncMode
+	Extra  SyncMode
 }
 
-var sqlDataType DSQLiteDataType
+func SynchronousMode() SyncModes {
+	return SyncModes{
+		Off:    "OFF",
+		Normal: "NORMAL",
+		Full:   "FULL",
+		Extra:  "EXTRA",
+	}
+}
 
 type DSQLiteDataType struct {
 	NULL    string
@@ -39,18 +59,16 @@ type DSQLiteDataType struct {
 }
 
 func SQLiteDataType() DSQLiteDataType {
-	sqlDataType.NULL = "NULL"
-	sqlDataType.TEXT = "TEXT"
-	sqlDataType.INTEGER = "INTEGER"
-	sqlDataType.REAL = "REAL"
-	sqlDataType.BLOB = "BLOB"
-	sqlDataType.VARIANT = "VARIANT"
-
-	return sqlDataType
+	return DSQLiteDataType{
+		NULL:    "NULL",
+		TEXT:    "TEXT",
+		INTEGER: "INTEGER",
+		REAL:    "REAL",
+		BLOB:    "BLOB",
+		VARIANT: "VARIANT",
+	}
 }
 
-var cmd CCmdParam
-
 type CCmdParam struct {
 	CollationList      string
 	CheckPointFullSync string
@@ -65,18 +83,18 @@ type CCmdParam struct {
 }
 
 func CmdParam() CCmdParam {
-	cmd.CollationList = db_cmd_get_collation_list
-	cmd.CheckPointFullSync = db_cmd_get_checkpoint_full_sync
-	cmd.DataVersion = db_cmd_get_data_version
-	cmd.ListDatabase = db_cmd_get_database_list
-	cmd.PageCount = db_cmd_get_page_count
-	cmd.Encoding = db_cmd_get_encoding
-	cmd.ModuleList = db_cmd_get_module_list
-	cmd.TableList = db_cmd_get_table_list
-	cmd.PageSize = db_cmd_get_page_size
-	cmd.SchemaVersion = db_cmd_get_schema_version
-
-	return cmd
+	return CCmdParam{
+		CollationList:      db_cmd_get_collation_list,
+		CheckPointFullSync: db_cmd_get_checkpoint_full_sync,
+		DataVersion:        db_cmd_get_data_version,
+		ListDatabase:       db_cmd_get_database_list,
+		PageCount:          db_cmd_get_page_count,
+		Encoding:           db_cmd_get_encoding,
+		ModuleList:         db_cmd_get_module_list,
+		TableList:          db_cmd_get_table_list,
+		PageSize:           db_cmd_get_page_size,
+		SchemaVersion:      db_cmd_get_schema_version,
+	}
 }
 
 const (
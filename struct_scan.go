@@ -0,0 +1,268 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ScanStruct copies the columns of the current row into the fields of
+// dest, which must be a pointer to a struct. A column is matched to a
+// field by the db tag of the field and then by the field name (both
+// case-insensitive); columns without a matching field are skipped.
+// The fields of embedded structs are included; a pointer field is set
+// to nil for NULL, e.g.
+//
+//	type Person struct {
+//		ID    int64   `db:"id"`
+//		Name  string  `db:"name"`
+//		Notes *string // nil when NULL
+//	}
+//
+//	for rows.Next() {
+//		var p Person
+//		if err := rows.ScanStruct(&p); err != nil {
+//			return err
+//		}
+//	}
+func (rs *Rows) ScanStruct(dest any) error {
+
+	if rs == nil || rs.stmt == nil || rs.stmt.released || rs.closed {
+		return errors.New("row is already closed")
+	}
+
+	v, err := structValue(dest)
+	if err != nil {
+		return err
+	}
+
+	cols, err := rs.Columns()
+	if err != nil {
+		return err
+	}
+
+	mu := rs.db.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return setStructFields(v, cols, func(i int) any {
+		return rs.db.getStmtColVal(rs.stmt, i)
+	})
+}
+
+// ToStructs copies the rows of the DataTable into the slice that
+// slicePtr points to; the elements of the slice can be structs or
+// pointers to structs. The columns are matched to the fields as in
+// Rows.ScanStruct(). e.g.
+//
+//	var people []Person
+//	err := dt.ToStructs(&people)
+func (dt *DataTable) ToStructs(slicePtr any) error {
+
+	pv := reflect.ValueOf(slicePtr)
+	if pv.Kind() != reflect.Pointer || pv.IsNil() || pv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice not %T", slicePtr)
+	}
+
+	sv := pv.Elem()
+	elemType := sv.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a slice of structs not %s", sv.Type())
+	}
+
+	cols := make([]string, len(dt.Columns))
+	for i := range dt.Columns {
+		cols[i] = dt.Columns[i].Name
+	}
+
+	list := reflect.MakeSlice(sv.Type(), 0, len(dt.Rows))
+	for r := range dt.Rows {
+		ev := reflect.New(elemType)
+		row := dt.Rows[r]
+		if len(cols) == 0 {
+			// a DataTable built by the caller may have
+			// no Columns; take them from the row.
+			for k := range row {
+				cols = append(cols, k)
+			}
+		}
+		err := setStructFields(ev.Elem(), cols, func(i int) any {
+			return row[cols[i]]
+		})
+		if err != nil {
+			return fmt.Errorf("row %d: %w", r, err)
+		}
+		if isPtr {
+			list = reflect.Append(list, ev)
+		} else {
+			list = reflect.Append(list, ev.Elem())
+		}
+	}
+
+	sv.Set(list)
+
+	return nil
+}
+
+// structValue returns the struct that dest points to.
+func structValue(dest any) (reflect.Value, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("expected a pointer to a struct not %T", dest)
+	}
+
+	v = v.Elem()
+	if v.Kind() != reflect.Struct || v.Type() == reflect.TypeOf(time.Time{}) {
+		return reflect.Value{}, fmt.Errorf("expected a pointer to a struct not %T", dest)
+	}
+
+	return v, nil
+}
+
+// structField is a field of a struct (or of one of
+// its embedded structs) by its index path.
+type structField struct {
+	tag   string
+	name  string
+	index []int
+}
+
+// structFields lists the exported fields of a struct type; the
+// fields of embedded structs follow the fields of their parent.
+func structFields(t reflect.Type, parent []int) []structField {
+	var flds []structField
+	var embedded []reflect.StructField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		tag, _, _ = strings.Cut(tag, ",")
+
+		index := append(append([]int{}, parent...), i)
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}) {
+				f.Index = index
+				embedded = append(embedded, f)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		flds = append(flds, structField{tag: tag, name: f.Name, index: index})
+	}
+
+	for i := range embedded {
+		ft := embedded[i].Type
+		if ft.Kind() == reflect.Pointer {
+			if !embedded[i].IsExported() {
+				// can not allocate an unexported pointer
+				continue
+			}
+			ft = ft.Elem()
+		}
+		flds = append(flds, structFields(ft, embedded[i].Index)...)
+	}
+
+	return flds
+}
+
+// findStructField returns the field for a column; the db tags
+// are matched first and then the field names.
+func findStructField(flds []structField, colName string) (structField, bool) {
+	for i := range flds {
+		if flds[i].tag != "" && strings.EqualFold(flds[i].tag, colName) {
+			return flds[i], true
+		}
+	}
+	for i := range flds {
+		if flds[i].tag == "" && strings.EqualFold(flds[i].name, colName) {
+			return flds[i], true
+		}
+	}
+
+	return structField{}, false
+}
+
+// setStructFields sets the fields of v from the column values;
+// colVal returns the value of the column at index i.
+func setStructFields(v reflect.Value, cols []string, colVal func(i int) any) error {
+
+	flds := structFields(v.Type(), nil)
+
+	for i := range cols {
+		f, ok := findStructField(flds, cols[i])
+		if !ok {
+			continue
+		}
+
+		fv := fieldByIndex(v, f.index)
+		val := colVal(i)
+
+		if val == nil {
+			// NULL
+			if sc, ok := fv.Addr().Interface().(sql.Scanner); ok {
+				if err := sc.Scan(nil); err != nil {
+					return fmt.Errorf("column %s: %w", cols[i], err)
+				}
+				continue
+			}
+			fv.SetZero()
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() != reflect.Pointer {
+			pv := reflect.New(fv.Type().Elem())
+			if err := assignColVal(val, pv.Interface(), i); err != nil {
+				return fmt.Errorf("column %s: %w", cols[i], err)
+			}
+			fv.Set(pv)
+			continue
+		}
+
+		if err := assignColVal(val, fv.Addr().Interface(), i); err != nil {
+			return fmt.Errorf("column %s: %w", cols[i], err)
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the field of v by its index path; nil
+// pointers to embedded structs are allocated on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"testing"
+)
+
+type scanBase struct {
+	ID int64 `db:"person_id"`
+}
+
+type scanPerson struct {
+	scanBase
+	Name   string
+	Score  float64 `db:"SCORE"`
+	Notes  *string
+	Hidden string `db:"-"`
+}
+
+func openPersonTable(t *testing.T) *DB {
+	t.Helper()
+
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table person(person_id integer, name text, score real, notes text, other text, hidden text)")
+	mustExec(t, db, "insert into person values(1, 'a', 1.5, 'note', 'x', 'h')")
+	mustExec(t, db, "insert into person values(2, 'b', 2.5, null, 'y', 'h')")
+
+	return db
+}
+
+func checkPeople(t *testing.T, people []scanPerson) {
+	t.Helper()
+
+	if len(people) != 2 {
+		t.Fatalf("got %d rows, want 2", len(people))
+	}
+	p := people[0]
+	if p.ID != 1 || p.Name != "a" || p.Score != 1.5 || p.Notes == nil || *p.Notes != "note" || p.Hidden != "" {
+		t.Fatalf("got %+v", p)
+	}
+	p = people[1]
+	if p.ID != 2 || p.Name != "b" || p.Score != 2.5 || p.Notes != nil {
+		t.Fatalf("got %+v", p)
+	}
+}
+
+func TestScanStruct(t *testing.T) {
+	db := openPersonTable(t)
+
+	rows, err := db.Query("select * from person order by person_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var people []scanPerson
+	for rows.Next() {
+		var p scanPerson
+		if err := rows.ScanStruct(p); err == nil {
+			t.Fatal("no error for a struct that is not a pointer")
+		}
+		if err := rows.ScanStruct(&p); err != nil {
+			t.Fatal(err)
+		}
+		people = append(people, p)
+	}
+	checkPeople(t, people)
+}
+
+func TestToStructs(t *testing.T) {
+	db := openPersonTable(t)
+
+	dt, err := db.GetDataTable("select * from person order by person_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var people []scanPerson
+	if err := dt.ToStructs(&people); err != nil {
+		t.Fatal(err)
+	}
+	checkPeople(t, people)
+
+	var ptrs []*scanPerson
+	if err := dt.ToStructs(&ptrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(ptrs) != 2 || ptrs[1].Name != "b" {
+		t.Fatalf("got %v", ptrs)
+	}
+
+	var ints []int
+	if err := dt.ToStructs(&ints); err == nil {
+		t.Fatal("no error for a slice of int")
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TxMode is how a transaction acquires the lock of the database.
+// See: https://www.sqlite.org/lang_transaction.html
+type TxMode int
+
+const (
+	// Deferred acquires the locks on the first read/write.
+	Deferred TxMode = iota
+
+	// Immediate acquires the write lock at BEGIN; other
+	// connections can still read (WAL).
+	Immediate
+
+	// Exclusive acquires the write lock at BEGIN; in a
+	// journal mode other than WAL, other connections
+	// cannot read either.
+	Exclusive
+)
+
+func (m TxMode) String() string {
+	switch m {
+	case Deferred:
+		return "DEFERRED"
+	case Immediate:
+		return "IMMEDIATE"
+	case Exclusive:
+		return "EXCLUSIVE"
+	}
+
+	return fmt.Sprintf("TxMode(%d)", int(m))
+}
+
+// TxOptions are the options of DB.BeginTx().
+type TxOptions struct {
+	Mode TxMode
+
+	// ReadOnly sets PRAGMA query_only for the lifetime of the
+	// transaction; it can only be used with Deferred.
+	ReadOnly bool
+}
+
+// ErrTxDone is returned by the methods of a Tx that has already
+// been committed or rolled back; it is the same as sql.ErrTxDone.
+var ErrTxDone = sql.ErrTxDone
+
+// Tx is a transaction started by DB.BeginTx(). The statements of
+// the transaction must be run via the Tx (not via the DB). While the
+// transaction is open, the statements of the DB on the writer
+// connection (Exec(), Execute(), ExecScript(), Stmt.Exec(), ...) wait
+// until it is committed or rolled back; so they are neither part of
+// it nor see its changes. The queries of the DB (Query(),
+// GetDataTable(), ...) run on a reader connection (in WAL mode) and
+// see the database as of the last commit; or else they wait as well.
+// So a goroutine must not use the DB while it has a Tx open.
+type Tx struct {
+	db       *DB
+	ctx      context.Context
+	readOnly bool
+
+	// stopWatch stops the rollback of the transaction
+	// when ctx is done; see BeginTx().
+	stopWatch func() bool
+
+	mu   sync.Mutex
+	done bool
+}
+
+// Savepoint is a nested transaction inside a Tx; see Tx.Savepoint().
+type Savepoint struct {
+	tx   *Tx
+	name string
+	done bool
+}
+
+// BeginTx starts a transaction. ctx applies to all statements of the
+// transaction; if ctx is done before Commit(), the transaction is
+// rolled back right away (so an abandoned Tx does not hold up the
+// other writes of the DB). e.g.
+//
+//	tx, err := db.BeginTx(ctx, gosqlite.TxOptions{Mode: gosqlite.Immediate})
+//	if err != nil {
+//		return err
+//	}
+//	defer tx.Rollback()
+//
+//	if res := tx.Exec("update account set balance = balance - ? where id = ?", 10, 1); res.Error() != nil {
+//		return res.Error()
+//	}
+//	...
+//	return tx.Commit()
+func (d *DB) BeginTx(ctx context.Context, opts TxOptions) (*Tx, error) {
+	if d == nil || d.Closed {
+		return nil, errors.New("database is not open")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch opts.Mode {
+	case Deferred, Immediate, Exclusive:
+	default:
+		return nil, fmt.Errorf("invalid transaction mode %s", opts.Mode)
+	}
+	if opts.ReadOnly && opts.Mode != Deferred {
+		return nil, fmt.Errorf("a read-only transaction cannot be %s", opts.Mode)
+	}
+
+	// other writes wait for the transaction; see doExec().
+	d.mutex.Lock()
+
+	if opts.ReadOnly {
+		res := d.execDo(ctx, "PRAGMA query_only = 1")
+		if res.Error() != nil {
+			d.mutex.Unlock()
+			return nil, res.Error()
+		}
+	}
+
+	// a locked database is retried by execDo()
+	// per the retry policy.
+	res := d.execDo(ctx, "BEGIN "+opts.Mode.String())
+	if res.Error() != nil {
+		if opts.ReadOnly {
+			d.execDo(context.Background(), "PRAGMA query_only = 0")
+		}
+		d.mutex.Unlock()
+		return nil, res.Error()
+	}
+
+	d.inTx.Store(true)
+
+	tx := &Tx{db: d, ctx: ctx, readOnly: opts.ReadOnly}
+
+	// an abandoned transaction would hold up the other writes
+	tx.stopWatch = context.AfterFunc(ctx, func() {
+		tx.Rollback()
+	})
+
+	return tx, nil
+}
+
+// WithTx runs fn in a transaction; the transaction is committed if fn
+// returns nil, or else rolled back (also if fn panics). opts is
+// optional; the default mode is Immediate. In the Immediate mode, if
+// the transaction fails with SQLITE_BUSY (i.e. the database is locked
+// by another connection or process), it is rolled back and fn is run
+// again in a new transaction; up to the MaxAttempts of the retry policy
+// of the database (see SetRetryPolicy()). So fn must not have other
+// effects than the ones of its Tx. e.g.
+//
+//	err := db.WithTx(ctx, func(tx *gosqlite.Tx) error {
+//		res := tx.Exec("insert into person(name) values(?)", "Jane")
+//		return res.Error()
+//	})
+func (d *DB) WithTx(ctx context.Context, fn func(tx *Tx) error, opts ...TxOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	opt := TxOptions{Mode: Immediate}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	p := d.RetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		err := d.withTx(ctx, fn, opt)
+		if err == nil || opt.Mode != Immediate || !errors.Is(err, ErrBusy) || attempt >= p.MaxAttempts {
+			return err
+		}
+		if !sleepCtx(ctx, p.wait(attempt)) {
+			return err
+		}
+	}
+}
+
+// withTx is an attempt of WithTx().
+func (d *DB) withTx(ctx context.Context, fn func(tx *Tx) error, opt TxOptions) error {
+	tx, err := d.BeginTx(ctx, opt)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Exec runs sql statement(s) in the transaction; see DB.Exec().
+func (tx *Tx) Exec(query string, placeHolders ...any) Result {
+	var res Result
+	if err := tx.check(); err != nil {
+		res.rowsAffected = -1
+		res.err = err
+		return res
+	}
+
+	return tx.db.execDo(tx.ctx, query, placeHolders...)
+}
+
+// Query runs a query in the transaction; see DB.Query(). The Rows
+// must be closed before the transaction is committed or rolled back.
+func (tx *Tx) Query(query string, placeHolders ...any) (*Rows, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// on the writer connection; see readConn().
+	return tx.db.query(tx.ctx, query, placeHolders...)
+}
+
+// GetDataTable runs a query in the transaction and returns its
+// result as a DataTable; see DB.GetDataTable().
+func (tx *Tx) GetDataTable(query string, placeHolders ...any) (*DataTable, error) {
+	if err := tx.check(); err != nil {
+		return new(DataTable), err
+	}
+
+	// on the writer connection; see readConn().
+	return tx.db.getDataTableWithContext(tx.ctx, query, placeHolders...)
+}
+
+// Prepare compiles a statement of the transaction; see DB.Prepare().
+// The statement can be used until the transaction is over, and must
+// be closed by the caller.
+func (tx *Tx) Prepare(query string, placeHolders []any) (Stmt, string, error) {
+	if err := tx.check(); err != nil {
+		return Stmt{}, "", err
+	}
+
+	s, tail, err := tx.db.Prepare(query, placeHolders)
+	s.tx = tx
+
+	return s, tail, err
+}
+
+// Savepoint starts a nested transaction that can be rolled back
+// without rolling back the Tx; name must be unique within the Tx.
+// e.g.
+//
+//	sp, err := tx.Savepoint("import")
+//	if err != nil {
+//		return err
+//	}
+//	if err := importRows(tx); err != nil {
+//		sp.Rollback() // the Tx goes on
+//	} else {
+//		sp.Release()
+//	}
+//
+// See: https://www.sqlite.org/lang_savepoint.html
+func (tx *Tx) Savepoint(name string) (*Savepoint, error) {
+	if name == "" {
+		return nil, errors.New("savepoint name is empty")
+	}
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	sp := Savepoint{tx: tx, name: name}
+	res := tx.db.execDo(tx.ctx, "SAVEPOINT "+sp.quotedName())
+	if res.Error() != nil {
+		return nil, res.Error()
+	}
+
+	return &sp, nil
+}
+
+// Release keeps the changes since the savepoint (as a part of the Tx)
+// and ends the savepoint, incl. the ones started after it.
+func (sp *Savepoint) Release() error {
+	if sp.done {
+		return ErrTxDone
+	}
+	if err := sp.tx.check(); err != nil {
+		return err
+	}
+
+	res := sp.tx.db.execDo(sp.tx.ctx, "RELEASE "+sp.quotedName())
+	if res.Error() != nil {
+		return res.Error()
+	}
+	sp.done = true
+
+	return nil
+}
+
+// Rollback undoes the changes since the savepoint and ends
+// the savepoint; the Tx is not rolled back.
+func (sp *Savepoint) Rollback() error {
+	if sp.done {
+		return ErrTxDone
+	}
+	if err := sp.tx.check(); err != nil {
+		return err
+	}
+
+	res := sp.tx.db.execDo(context.Background(), "ROLLBACK TO "+sp.quotedName())
+	if res.Error() != nil {
+		return res.Error()
+	}
+
+	// the savepoint stays on the stack after ROLLBACK TO.
+	res = sp.tx.db.execDo(context.Background(), "RELEASE "+sp.quotedName())
+	if res.Error() != nil {
+		return res.Error()
+	}
+	sp.done = true
+
+	return nil
+}
+
+func (sp *Savepoint) quotedName() string {
+	return quoteIdent(sp.name)
+}
+
+// Commit commits the transaction; if ctx of the transaction is
+// done, or the commit fails, the transaction is rolled back.
+func (tx *Tx) Commit() error {
+	if err := tx.finish(); err != nil {
+		if tx.ctx.Err() != nil {
+			// rolled back, when ctx was done
+			return tx.ctx.Err()
+		}
+		return err
+	}
+	defer tx.end()
+
+	if err := tx.ctx.Err(); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	// a locked database is not retried in a transaction
+	// (see retry()); the transaction is rolled back.
+	res := tx.db.execDo(context.Background(), "COMMIT")
+	if res.Error() != nil {
+		if !tx.db.AutoCommit() {
+			tx.rollback()
+		}
+		return res.Error()
+	}
+
+	return nil
+}
+
+// Rollback rolls back the transaction; ErrTxDone is returned, if
+// the transaction has already been committed or rolled back; so it
+// can be deferred right after BeginTx().
+func (tx *Tx) Rollback() error {
+	if err := tx.finish(); err != nil {
+		return err
+	}
+	defer tx.end()
+
+	return tx.rollback()
+}
+
+func (tx *Tx) rollback() error {
+	if tx.db.AutoCommit() {
+		// sqlite3 has already rolled back the transaction;
+		// e.g. on SQLITE_FULL.
+		return nil
+	}
+
+	// not canceled by ctx.
+	res := tx.db.execDo(context.Background(), "ROLLBACK")
+
+	return res.Error()
+}
+
+// check returns ErrTxDone, if the transaction is over.
+func (tx *Tx) check() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+
+	return nil
+}
+
+// finish marks the transaction as done, for Commit() or Rollback();
+// ErrTxDone, if it is already done. So only one of them (e.g. the
+// rollback when ctx is done) ends the transaction.
+func (tx *Tx) finish() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	return nil
+}
+
+// end ends the transaction and lets the other writes go on;
+// it is called once, after finish().
+func (tx *Tx) end() {
+	if tx.stopWatch != nil {
+		tx.stopWatch()
+	}
+
+	if tx.readOnly {
+		tx.db.execDo(context.Background(), "PRAGMA query_only = 0")
+	}
+
+	tx.db.inTx.Store(false)
+	tx.db.mutex.Unlock()
+}
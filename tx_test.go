@@ -0,0 +1,287 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTxCommitAndRollback(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	tx, err := db.BeginTx(context.Background(), TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := tx.Exec("insert into t values(1)"); res.Error() != nil {
+		t.Fatal(res.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Fatalf("second Commit: got %v, want ErrTxDone", err)
+	}
+
+	tx, err = db.BeginTx(context.Background(), TxOptions{Mode: Immediate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("insert into t values(2)")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
+
+func TestTxIsolation(t *testing.T) {
+	db := openTestDB(t, true)
+	mustExec(t, db, "create table t(a)")
+
+	tx, err := db.BeginTx(context.Background(), TxOptions{Mode: Immediate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	tx.Exec("insert into t values(1)")
+
+	// a query of the DB runs on a reader; it does not
+	// see the uncommitted row.
+	if n := count(t, db, "select count(*) from t"); n != 0 {
+		t.Fatalf("a query of the DB sees %d uncommitted rows", n)
+	}
+
+	// a write of the DB waits for the Tx.
+	done := make(chan error, 1)
+	go func() {
+		res := db.Exec("insert into t values(2)")
+		done <- res.Error()
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Exec did not wait for the Tx: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db, "select count(*) from t"); n != 2 {
+		t.Fatalf("got %d rows, want 2", n)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	errFail := errors.New("fail")
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		tx.Exec("insert into t values(1)")
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("got %v, want %v", err, errFail)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the panic of fn was not passed on")
+			}
+		}()
+		db.WithTx(context.Background(), func(tx *Tx) error {
+			tx.Exec("insert into t values(2)")
+			panic("fail")
+		})
+	}()
+
+	calls := 0
+	err = db.WithTx(context.Background(), func(tx *Tx) error {
+		calls++
+		res := tx.Exec("insert into t values(3)")
+		return res.Error()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("fn was called %d times", calls)
+	}
+
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
+
+func TestTxSavepoint(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		tx.Exec("insert into t values(1)")
+
+		sp, err := tx.Savepoint("a")
+		if err != nil {
+			return err
+		}
+		tx.Exec("insert into t values(2)")
+		if err := sp.Rollback(); err != nil {
+			return err
+		}
+
+		sp, err = tx.Savepoint("b")
+		if err != nil {
+			return err
+		}
+		tx.Exec("insert into t values(3)")
+
+		return sp.Release()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db, "select count(*) from t where a in (1, 3)"); n != 2 {
+		t.Fatalf("got %d rows, want 2", n)
+	}
+	if n := count(t, db, "select count(*) from t"); n != 2 {
+		t.Fatalf("got %d rows, want 2", n)
+	}
+}
+
+func TestTxPrepare(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	tx, err := db.BeginTx(context.Background(), TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	s, _, err := tx.Prepare("insert into t values(?)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := range 3 {
+		s.Bind(i)
+		if res := s.Exec(); res.Error() != nil {
+			t.Fatal(res.Error())
+		}
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db, "select count(*) from t"); n != 0 {
+		t.Fatalf("got %d rows after the rollback", n)
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+	db.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	// fn is run again in a new transaction
+	calls := 0
+	err := db.WithTx(context.Background(), func(tx *Tx) error {
+		calls++
+		tx.Exec("insert into t values(?)", calls)
+		if calls == 1 {
+			return fmt.Errorf("insert: %w", ErrBusy)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("fn was called %d times, want 2", calls)
+	}
+	if n := count(t, db, "select count(*) from t where a = 2"); n != 1 {
+		t.Fatal("the second attempt is not committed")
+	}
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatal("the first attempt is not rolled back")
+	}
+
+	// up to MaxAttempts
+	calls = 0
+	err = db.WithTx(context.Background(), func(tx *Tx) error {
+		calls++
+		return ErrBusy
+	})
+	if !errors.Is(err, ErrBusy) || calls != 3 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+
+	// only in the Immediate mode
+	calls = 0
+	db.WithTx(context.Background(), func(tx *Tx) error {
+		calls++
+		return ErrBusy
+	}, TxOptions{Mode: Deferred})
+	if calls != 1 {
+		t.Fatalf("fn was called %d times in the Deferred mode", calls)
+	}
+}
+
+func TestTxContextDone(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := db.BeginTx(ctx, TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("insert into t values(1)")
+
+	// the abandoned Tx is rolled back; the writes go on
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		res := db.Exec("insert into t values(2)")
+		done <- res.Error()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Exec waits for a Tx of a done ctx")
+	}
+
+	if err := tx.Commit(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if res := tx.Exec("insert into t values(3)"); !errors.Is(res.Error(), ErrTxDone) {
+		t.Fatalf("got %v, want ErrTxDone", res.Error())
+	}
+	if n := count(t, db, "select count(*) from t"); n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testUUID is bound as TEXT and scanned from TEXT.
+type testUUID [2]byte
+
+func (u testUUID) Value() (driver.Value, error) {
+	return fmt.Sprintf("%02x-%02x", u[0], u[1]), nil
+}
+
+func (u *testUUID) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into testUUID", src)
+	}
+	_, err := fmt.Sscanf(s, "%02x-%02x", &u[0], &u[1])
+	return err
+}
+
+type testLevel int16
+
+func TestValuerScanner(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(id, name, n)")
+
+	id := testUUID{0xab, 0xcd}
+	mustExec(t, db, "insert into t values(?, ?, ?)", id, sql.NullString{String: "a", Valid: true}, sql.NullInt64{})
+	mustExec(t, db, "insert into t values(?, ?, ?)", testUUID{1, 2}, sql.NullString{}, testLevel(7))
+
+	if n := count(t, db, "select count(*) from t where id = 'ab-cd' and name = 'a' and n is null"); n != 1 {
+		t.Fatal("the values of driver.Valuer are not bound")
+	}
+
+	rows, err := db.Query("select id, name, n from t order by rowid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var u testUUID
+		var name sql.NullString
+		var n sql.NullInt64
+		if err := rows.Scan(&u, &name, &n); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%v %v %v", u, name, n))
+	}
+	want := []string{"[171 205] {a true} {0 false}", "[1 2] { false} {7 true}"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestScanWidths(t *testing.T) {
+	db := openTestDB(t, false)
+	mustExec(t, db, "create table t(a, b, c, d, e)")
+	mustExec(t, db, "insert into t values(?, ?, ?, ?, ?)",
+		int8(-8), uint32(32), uint64(math.MaxInt64), float32(1.5), 1000)
+
+	rows, err := db.Query("select a, b, c, d from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("no row")
+	}
+	var (
+		a int32
+		b uint16
+		c uint64
+		d float32
+	)
+	if err := rows.Scan(&a, &b, &c, &d); err != nil {
+		t.Fatal(err)
+	}
+	if a != -8 || b != 32 || c != math.MaxInt64 || d != 1.5 {
+		t.Fatalf("got %d %d %d %v", a, b, c, d)
+	}
+	rows.Close()
+
+	// a value that does not fit
+	if _, err := QueryOne[int8](db, "select e from t"); err == nil || !strings.Contains(err.Error(), "overflows") {
+		t.Fatalf("got %v, want an overflow error", err)
+	}
+	if _, err := QueryOne[uint32](db, "select a from t"); err == nil || !strings.Contains(err.Error(), "overflows") {
+		t.Fatalf("got %v, want an overflow error", err)
+	}
+
+	// uint64 above int64 max
+	res := db.Exec("insert into t(a) values(?)", uint64(math.MaxInt64)+1)
+	if err := res.Error(); err == nil || !strings.Contains(err.Error(), "overflows INTEGER") {
+		t.Fatalf("got %v, want an overflow error", err)
+	}
+}
@@ -0,0 +1,714 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+//#include <stdint.h>
+//#include <stdlib.h>
+//#include <string.h>
+//#include "sqlite3.h"
+// int go_sqlite3_vtab_connect(sqlite3 *db, uintptr_t h, int argc, char **argv, uintptr_t *pVTab, char **pzErr);
+// int go_sqlite3_vtab_disconnect(uintptr_t h, int destroy);
+// int go_sqlite3_vtab_best_index(uintptr_t h, sqlite3_vtab *vt, sqlite3_index_info *info);
+// int go_sqlite3_vtab_open(uintptr_t h, sqlite3_vtab *vt, uintptr_t *pCur);
+// int go_sqlite3_vtab_close(uintptr_t c);
+// int go_sqlite3_vtab_filter(uintptr_t c, sqlite3_vtab *vt, int idxNum, char *idxStr, int argc, sqlite3_value **argv);
+// int go_sqlite3_vtab_next(uintptr_t c, sqlite3_vtab *vt);
+// int go_sqlite3_vtab_eof(uintptr_t c);
+// int go_sqlite3_vtab_column(uintptr_t c, sqlite3_vtab *vt, sqlite3_context *ctx, int i);
+// int go_sqlite3_vtab_rowid(uintptr_t c, sqlite3_vtab *vt, sqlite3_int64 *pRowid);
+// int go_sqlite3_vtab_update(uintptr_t h, sqlite3_vtab *vt, int argc, sqlite3_value **argv, sqlite3_int64 *pRowid);
+// void go_sqlite3_destroy(uintptr_t h);
+//
+// typedef struct go_vtab {
+//   sqlite3_vtab base;
+//   uintptr_t h;
+// } go_vtab;
+//
+// typedef struct go_vtab_cursor {
+//   sqlite3_vtab_cursor base;
+//   uintptr_t h;
+// } go_vtab_cursor;
+//
+// static int vt_connect(sqlite3 *db, void *pAux, int argc, const char *const *argv, sqlite3_vtab **ppVTab, char **pzErr){
+//   uintptr_t h = 0;
+//   int rc = go_sqlite3_vtab_connect(db, (uintptr_t)pAux, argc, (char**)argv, &h, pzErr);
+//   if (rc != SQLITE_OK) return rc;
+//   go_vtab *vt = sqlite3_malloc(sizeof(go_vtab));
+//   if (vt == NULL) {
+//     go_sqlite3_vtab_disconnect(h, 0);
+//     return SQLITE_NOMEM;
+//   }
+//   memset(vt, 0, sizeof(go_vtab));
+//   vt->h = h;
+//   *ppVTab = &vt->base;
+//   return SQLITE_OK;
+// }
+// static int vt_disconnect(sqlite3_vtab *p){
+//   int rc = go_sqlite3_vtab_disconnect(((go_vtab*)p)->h, 0);
+//   sqlite3_free(p);
+//   return rc;
+// }
+// static int vt_destroy(sqlite3_vtab *p){
+//   // the table is kept by sqlite3, if it fails
+//   int rc = go_sqlite3_vtab_disconnect(((go_vtab*)p)->h, 1);
+//   if (rc == SQLITE_OK) sqlite3_free(p);
+//   return rc;
+// }
+// static int vt_best_index(sqlite3_vtab *p, sqlite3_index_info *info){
+//   return go_sqlite3_vtab_best_index(((go_vtab*)p)->h, p, info);
+// }
+// static int vt_open(sqlite3_vtab *p, sqlite3_vtab_cursor **ppCur){
+//   uintptr_t h = 0;
+//   int rc = go_sqlite3_vtab_open(((go_vtab*)p)->h, p, &h);
+//   if (rc != SQLITE_OK) return rc;
+//   go_vtab_cursor *c = sqlite3_malloc(sizeof(go_vtab_cursor));
+//   if (c == NULL) {
+//     go_sqlite3_vtab_close(h);
+//     return SQLITE_NOMEM;
+//   }
+//   memset(c, 0, sizeof(go_vtab_cursor));
+//   c->h = h;
+//   *ppCur = &c->base;
+//   return SQLITE_OK;
+// }
+// static int vt_close(sqlite3_vtab_cursor *p){
+//   int rc = go_sqlite3_vtab_close(((go_vtab_cursor*)p)->h);
+//   sqlite3_free(p);
+//   return rc;
+// }
+// static int vt_filter(sqlite3_vtab_cursor *p, int idxNum, const char *idxStr, int argc, sqlite3_value **argv){
+//   return go_sqlite3_vtab_filter(((go_vtab_cursor*)p)->h, p->pVtab, idxNum, (char*)idxStr, argc, argv);
+// }
+// static int vt_next(sqlite3_vtab_cursor *p){
+//   return go_sqlite3_vtab_next(((go_vtab_cursor*)p)->h, p->pVtab);
+// }
+// static int vt_eof(sqlite3_vtab_cursor *p){
+//   return go_sqlite3_vtab_eof(((go_vtab_cursor*)p)->h);
+// }
+// static int vt_column(sqlite3_vtab_cursor *p, sqlite3_context *ctx, int i){
+//   return go_sqlite3_vtab_column(((go_vtab_cursor*)p)->h, p->pVtab, ctx, i);
+// }
+// static int vt_rowid(sqlite3_vtab_cursor *p, sqlite3_int64 *pRowid){
+//   return go_sqlite3_vtab_rowid(((go_vtab_cursor*)p)->h, p->pVtab, pRowid);
+// }
+// static int vt_update(sqlite3_vtab *p, int argc, sqlite3_value **argv, sqlite3_int64 *pRowid){
+//   return go_sqlite3_vtab_update(((go_vtab*)p)->h, p, argc, argv, pRowid);
+// }
+//
+// // xCreate is xConnect; i.e. the module is eponymous.
+// static sqlite3_module go_module = {
+//   1, vt_connect, vt_connect, vt_best_index, vt_disconnect, vt_destroy,
+//   vt_open, vt_close, vt_filter, vt_next, vt_eof, vt_column, vt_rowid, vt_update,
+// };
+//
+// // no xCreate; i.e. the module is eponymous-only.
+// static sqlite3_module go_module_eponymous_only = {
+//   1, NULL, vt_connect, vt_best_index, vt_disconnect, vt_destroy,
+//   vt_open, vt_close, vt_filter, vt_next, vt_eof, vt_column, vt_rowid, vt_update,
+// };
+//
+// static void module_destroy(void *h){
+//   go_sqlite3_destroy((uintptr_t)h);
+// }
+// static int create_module(sqlite3 *db, const char *name, int eponymousOnly, uintptr_t h){
+//   const sqlite3_module *m = eponymousOnly ? &go_module_eponymous_only : &go_module;
+//   return sqlite3_create_module_v2(db, name, m, (void*)h, module_destroy);
+// }
+// static void vtab_set_error(sqlite3_vtab *vt, const char *msg){
+//   sqlite3_free(vt->zErrMsg);
+//   vt->zErrMsg = sqlite3_mprintf("%s", msg);
+// }
+// static char *vtab_mprintf(const char *s){
+//   return sqlite3_mprintf("%s", s);
+// }
+// static void index_set_str(sqlite3_index_info *info, const char *s){
+//   info->idxStr = sqlite3_mprintf("%s", s);
+//   info->needToFreeIdxStr = 1;
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// Module is a virtual table module; it exposes a Go data source
+// (e.g. a cache, a log file, a slice of structs) as an SQL table,
+// that can be queried (and joined with other tables) as any table.
+// See: https://www.sqlite.org/vtab.html
+type Module interface {
+	// Connect is called for a new table of the module, on each
+	// connection that uses it; i.e. by CREATE VIRTUAL TABLE, when
+	// an existing table is opened, and when the module is used as
+	// an eponymous table (i.e. by the name of the module, incl. as
+	// a table-valued function). args are the module name, the
+	// database name, the table name and the args of CREATE VIRTUAL
+	// TABLE (if any). schema is the CREATE TABLE statement of the
+	// columns of the table (the name of the table is ignored), e.g.
+	//
+	//	CREATE TABLE x(value INTEGER, start HIDDEN, stop HIDDEN)
+	//
+	// The HIDDEN columns are not in SELECT *; they are the args of
+	// a table-valued function, e.g. select value from series(1, 10).
+	Connect(args []string) (vt VTab, schema string, err error)
+}
+
+// VTab is a virtual table of a Module.
+type VTab interface {
+	// BestIndex chooses a query plan for the constraints of a
+	// query; see IndexInfo.
+	BestIndex(info *IndexInfo) error
+
+	// Open opens a cursor for a query of the table.
+	Open() (VTabCursor, error)
+
+	// Disconnect is called when the table is closed on a
+	// connection, or dropped (DROP TABLE).
+	Disconnect() error
+}
+
+// VTabCursor is a cursor of a VTab; the rows are iterated as:
+//
+//	Filter() ... for !EOF() { Column(); Rowid(); Next() } ... Close()
+//
+// Filter() can be called again (e.g. for each row of the outer
+// table of a join) before Close().
+type VTabCursor interface {
+	// Filter starts a query; idxNum and idxStr are as set by
+	// BestIndex(), and args are the values of the constraints
+	// by their IndexConstraint.ArgIndex.
+	Filter(idxNum int, idxStr string, args []any) error
+
+	// Next moves the cursor to the next row.
+	Next() error
+
+	// EOF reports whether the cursor is past the last row.
+	EOF() bool
+
+	// Column returns the value of a column of the current row
+	// (by its index in the schema); the value is converted as a
+	// place holder value is bound (see Exec()).
+	Column(col int) (any, error)
+
+	// Rowid returns the rowid of the current row.
+	Rowid() (int64, error)
+
+	// Close closes the cursor.
+	Close() error
+}
+
+// VTabUpdater is a VTab that can be written (INSERT, UPDATE and
+// DELETE); a VTab that does not implement it is read-only. values
+// are the values of all columns in the order of the schema (incl.
+// the HIDDEN ones); as an SQL value they are int64, float64, string,
+// []byte or nil.
+type VTabUpdater interface {
+	VTab
+
+	// Insert inserts a row and returns its rowid; rowid is nil
+	// if it was not given.
+	Insert(rowid *int64, values []any) (int64, error)
+
+	// Update updates the row of rowid; newRowid is rowid, unless
+	// the rowid is changed as well.
+	Update(rowid, newRowid int64, values []any) error
+
+	// Delete deletes the row of rowid.
+	Delete(rowid int64) error
+}
+
+// ConstraintOp is the operator of an IndexConstraint.
+// See: https://www.sqlite.org/c3ref/c_index_constraint_eq.html
+type ConstraintOp int
+
+const (
+	ConstraintEQ        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_EQ
+	ConstraintGT        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_GT
+	ConstraintLE        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_LE
+	ConstraintLT        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_LT
+	ConstraintGE        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_GE
+	ConstraintMatch     ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_MATCH
+	ConstraintLike      ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_LIKE
+	ConstraintGlob      ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_GLOB
+	ConstraintRegexp    ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_REGEXP
+	ConstraintNE        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_NE
+	ConstraintIsNot     ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_ISNOT
+	ConstraintIsNotNull ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_ISNOTNULL
+	ConstraintIsNull    ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_ISNULL
+	ConstraintIs        ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_IS
+	ConstraintLimit     ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_LIMIT
+	ConstraintOffset    ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_OFFSET
+	ConstraintFunction  ConstraintOp = C.SQLITE_INDEX_CONSTRAINT_FUNCTION
+)
+
+func (o ConstraintOp) String() string {
+	switch o {
+	case ConstraintEQ:
+		return "="
+	case ConstraintGT:
+		return ">"
+	case ConstraintLE:
+		return "<="
+	case ConstraintLT:
+		return "<"
+	case ConstraintGE:
+		return ">="
+	case ConstraintMatch:
+		return "MATCH"
+	case ConstraintLike:
+		return "LIKE"
+	case ConstraintGlob:
+		return "GLOB"
+	case ConstraintRegexp:
+		return "REGEXP"
+	case ConstraintNE:
+		return "!="
+	case ConstraintIsNot:
+		return "IS NOT"
+	case ConstraintIsNotNull:
+		return "IS NOT NULL"
+	case ConstraintIsNull:
+		return "IS NULL"
+	case ConstraintIs:
+		return "IS"
+	case ConstraintLimit:
+		return "LIMIT"
+	case ConstraintOffset:
+		return "OFFSET"
+	}
+	if o >= ConstraintFunction {
+		return "FUNCTION"
+	}
+
+	return fmt.Sprintf("ConstraintOp(%d)", int(o))
+}
+
+// IndexConstraint is a constraint (i.e. a term of the WHERE clause,
+// or an arg of a table-valued function) on a column; e.g. "a > ?".
+type IndexConstraint struct {
+	// Column is the index of the column in the schema;
+	// -1 for the rowid.
+	Column int
+	Op     ConstraintOp
+
+	// Usable is false, if the constraint can not be used by
+	// this plan; e.g. for a column of the inner table of a join.
+	Usable bool
+
+	// ArgIndex is set by BestIndex() to pass the value of the
+	// constraint to Filter(), as args[ArgIndex-1]; 0 means not
+	// used. The ArgIndex of the used constraints must be 1..n.
+	ArgIndex int
+
+	// Omit is set by BestIndex(), if the cursor only returns
+	// the rows that match the constraint; i.e. so that sqlite3
+	// does not check it again.
+	Omit bool
+}
+
+// IndexOrderBy is a term of the ORDER BY clause.
+type IndexOrderBy struct {
+	Column int
+	Desc   bool
+}
+
+// IndexInfo is the query of BestIndex(); it sets the ArgIndex (and
+// Omit) of the constraints it uses, IdxNum and/or IdxStr to tell the
+// plan to Filter(), and EstimatedCost. BestIndex() is called for
+// several plans of a query, and the one with the lowest cost is used.
+// An error that matches ErrConstraint (i.e. errors.Is()) rejects the
+// plan; e.g. if a required arg of a table-valued function is not
+// usable.
+// See: https://www.sqlite.org/vtab.html#the_xbestindex_method
+type IndexInfo struct {
+	Constraints []IndexConstraint
+	OrderBy     []IndexOrderBy
+
+	// ColUsed is a mask of the columns used by the query; the bit
+	// 63 is for all columns from the 64th.
+	ColUsed uint64
+
+	IdxNum int
+	IdxStr string
+
+	// OrderByConsumed is set, if the rows are in the order of
+	// OrderBy; i.e. so that sqlite3 does not sort them.
+	OrderByConsumed bool
+
+	EstimatedCost float64
+	EstimatedRows int64
+
+	// Unique is set, if the plan returns at most one row.
+	Unique bool
+}
+
+// ModuleOptions are the options of DB.RegisterModule().
+type ModuleOptions struct {
+	// EponymousOnly is for a module that can only be used by its
+	// name (e.g. as a table-valued function); i.e. CREATE VIRTUAL
+	// TABLE ... USING the module fails.
+	EponymousOnly bool
+}
+
+// goModule is a module; see RegisterModule().
+type goModule struct {
+	name string
+	m    Module
+}
+
+// goVTab is a table of a module on a connection.
+type goVTab struct {
+	name string
+	vt   VTab
+}
+
+// errVTabReadOnly is the error of a write to a VTab that is
+// not a VTabUpdater.
+var errVTabReadOnly = &Error{Code: SQLITE_READONLY, ExtendedCode: SQLITE_READONLY, Message: "virtual table is read-only", Offset: -1}
+
+// RegisterModule registers a virtual table module. A module is
+// eponymous; i.e. it can be queried by its name, without CREATE
+// VIRTUAL TABLE, e.g. as a table-valued function:
+//
+//	err := db.RegisterModule("series", seriesModule{}, gosqlite.ModuleOptions{EponymousOnly: true})
+//	...
+//	rows, err := db.Query("select value from series(1, 10) where value % 2 = 0")
+//
+// Otherwise, tables of the module are created (with args) as:
+//
+//	err = db.Exec("create virtual table temp.app_log using logfile('/var/log/app.log')")
+//
+// As with RegisterFunc(), the module is registered on all connections
+// of the database, so its tables are used concurrently (one VTab per
+// connection); a module with the same name is replaced. See SliceTable
+// for a ready-made module of a slice of structs.
+// See: https://www.sqlite.org/c3ref/create_module.html
+func (d *DB) RegisterModule(name string, m Module, opts ModuleOptions) error {
+	if d == nil || d.Closed || d.DBHwnd == nil {
+		return errors.New("database is not open")
+	}
+	if name == "" {
+		return errors.New("module name is empty")
+	}
+	if m == nil {
+		return fmt.Errorf("%s: module is nil", name)
+	}
+
+	gm := goModule{name: name, m: m}
+
+	mu := d.connLock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	return d.registry.add(d.DBHwnd, "module/"+name, func(hwnd *C.sqlite3) error {
+		cName := C.CString(name)
+		defer C.free(unsafe.Pointer(cName))
+
+		eponymousOnly := C.int(0)
+		if opts.EponymousOnly {
+			eponymousOnly = 1
+		}
+
+		// h is deleted by sqlite3, incl. on error
+		h := cgo.NewHandle(&gm)
+
+		rc := C.create_module(hwnd, cName, eponymousOnly, C.uintptr_t(h))
+		if rc != SQLITE_OK {
+			return getSQLiteErr(rc, hwnd)
+		}
+
+		return nil
+	})
+}
+
+// vtabCall calls a method of a VTab (or of a VTabCursor); a
+// panic is recovered as an error.
+func vtabCall(name string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: panic: %v", name, r)
+		}
+	}()
+
+	return fn()
+}
+
+// vtabError sets the error message of a table, and returns
+// the result code of err.
+func vtabError(vt *C.sqlite3_vtab, err error) C.int {
+	msg := C.CString(err.Error())
+	defer C.free(unsafe.Pointer(msg))
+
+	C.vtab_set_error(vt, msg)
+
+	var e *Error
+	if errors.As(err, &e) && e.Code != 0 {
+		if e.ExtendedCode != 0 {
+			return C.int(e.ExtendedCode)
+		}
+		return C.int(e.Code)
+	}
+
+	return SQLITE_ERROR
+}
+
+// connect connects a table of the module (xCreate and xConnect),
+// and declares its schema.
+func (m *goModule) connect(db *C.sqlite3, args []string, pVTab *C.uintptr_t, pzErr **C.char) C.int {
+	var vt VTab
+	var schema string
+
+	err := vtabCall(m.name, func() error {
+		var err error
+		vt, schema, err = m.m.Connect(args)
+		return err
+	})
+	if err == nil && vt == nil {
+		err = fmt.Errorf("%s: Connect() returned a nil VTab", m.name)
+	}
+	if err != nil {
+		msg := C.CString(err.Error())
+		defer C.free(unsafe.Pointer(msg))
+
+		*pzErr = C.vtab_mprintf(msg)
+		return SQLITE_ERROR
+	}
+
+	cSchema := C.CString(schema)
+	defer C.free(unsafe.Pointer(cSchema))
+
+	if rc := C.sqlite3_declare_vtab(db, cSchema); rc != SQLITE_OK {
+		msg := C.CString(fmt.Sprintf("%s: %s", m.name, C.GoString(C.sqlite3_errmsg(db))))
+		defer C.free(unsafe.Pointer(msg))
+
+		*pzErr = C.vtab_mprintf(msg)
+		vtabCall(m.name, vt.Disconnect)
+		return rc
+	}
+
+	*pVTab = C.uintptr_t(cgo.NewHandle(&goVTab{name: m.name, vt: vt}))
+
+	return SQLITE_OK
+}
+
+// disconnect closes a table (xDisconnect and xDestroy); its
+// handle is deleted, unless it fails to be dropped.
+func (t *goVTab) disconnect(h cgo.Handle, destroy bool) C.int {
+	err := vtabCall(t.name, t.vt.Disconnect)
+	if err != nil && destroy {
+		return SQLITE_ERROR
+	}
+	h.Delete()
+
+	return SQLITE_OK
+}
+
+// bestIndex calls the BestIndex() of a table with the constraints
+// of info, and sets the plan it chose.
+func (t *goVTab) bestIndex(vt *C.sqlite3_vtab, info *C.sqlite3_index_info) C.int {
+	ii := IndexInfo{
+		ColUsed:       uint64(info.colUsed),
+		EstimatedCost: float64(info.estimatedCost),
+		EstimatedRows: int64(info.estimatedRows),
+	}
+
+	cons := unsafe.Slice(info.aConstraint, int(info.nConstraint))
+	for _, c := range cons {
+		ii.Constraints = append(ii.Constraints, IndexConstraint{
+			Column: int(c.iColumn),
+			Op:     ConstraintOp(c.op),
+			Usable: c.usable != 0,
+		})
+	}
+
+	for _, o := range unsafe.Slice(info.aOrderBy, int(info.nOrderBy)) {
+		ii.OrderBy = append(ii.OrderBy, IndexOrderBy{
+			Column: int(o.iColumn),
+			Desc:   o.desc != 0,
+		})
+	}
+
+	if err := vtabCall(t.name, func() error { return t.vt.BestIndex(&ii) }); err != nil {
+		return vtabError(vt, err)
+	}
+
+	usage := unsafe.Slice(info.aConstraintUsage, len(cons))
+	for i := range min(len(usage), len(ii.Constraints)) {
+		c := ii.Constraints[i]
+		if c.ArgIndex < 0 || c.ArgIndex > len(cons) {
+			return vtabError(vt, fmt.Errorf("%s: ArgIndex %d of constraint %d is out of range", t.name, c.ArgIndex, i))
+		}
+		usage[i].argvIndex = C.int(c.ArgIndex)
+		if c.Omit {
+			usage[i].omit = 1
+		}
+	}
+
+	info.idxNum = C.int(ii.IdxNum)
+	if ii.IdxStr != "" {
+		cs := C.CString(ii.IdxStr)
+		defer C.free(unsafe.Pointer(cs))
+
+		C.index_set_str(info, cs)
+	}
+	if ii.OrderByConsumed {
+		info.orderByConsumed = 1
+	}
+	info.estimatedCost = C.double(ii.EstimatedCost)
+	info.estimatedRows = C.sqlite3_int64(ii.EstimatedRows)
+	if ii.Unique {
+		info.idxFlags |= C.SQLITE_INDEX_SCAN_UNIQUE
+	}
+
+	return SQLITE_OK
+}
+
+// open opens a cursor of a table.
+func (t *goVTab) open(vt *C.sqlite3_vtab, pCur *C.uintptr_t) C.int {
+	var cur VTabCursor
+
+	err := vtabCall(t.name, func() error {
+		var err error
+		cur, err = t.vt.Open()
+		return err
+	})
+	if err == nil && cur == nil {
+		err = fmt.Errorf("%s: Open() returned a nil cursor", t.name)
+	}
+	if err != nil {
+		return vtabError(vt, err)
+	}
+
+	*pCur = C.uintptr_t(cgo.NewHandle(&goCursor{name: t.name, cur: cur}))
+
+	return SQLITE_OK
+}
+
+// update calls the Insert(), Update() or Delete() of a table; argv
+// is as by xUpdate, i.e. the old rowid (NULL for an INSERT), the new
+// rowid (if not a DELETE) and the values of the columns.
+func (t *goVTab) update(vt *C.sqlite3_vtab, argv []*C.sqlite3_value, pRowid *C.sqlite3_int64) C.int {
+	u, ok := t.vt.(VTabUpdater)
+	if !ok {
+		return vtabError(vt, errVTabReadOnly)
+	}
+
+	err := vtabCall(t.name, func() error {
+		if len(argv) == 1 {
+			return u.Delete(int64(C.sqlite3_value_int64(argv[0])))
+		}
+
+		values := make([]any, len(argv)-2)
+		for i := range values {
+			values[i] = sqlValue(argv[i+2])
+		}
+
+		if C.sqlite3_value_type(argv[0]) == SQLITE_NULL {
+			var rowid *int64
+			if C.sqlite3_value_type(argv[1]) != SQLITE_NULL {
+				id := int64(C.sqlite3_value_int64(argv[1]))
+				rowid = &id
+			}
+
+			id, err := u.Insert(rowid, values)
+			if err != nil {
+				return err
+			}
+			*pRowid = C.sqlite3_int64(id)
+			return nil
+		}
+
+		return u.Update(int64(C.sqlite3_value_int64(argv[0])), int64(C.sqlite3_value_int64(argv[1])), values)
+	})
+	if err != nil {
+		return vtabError(vt, err)
+	}
+
+	return SQLITE_OK
+}
+
+// goCursor is a cursor of a table.
+type goCursor struct {
+	name string
+	cur  VTabCursor
+}
+
+func (c *goCursor) filter(vt *C.sqlite3_vtab, idxNum int, idxStr string, argv []*C.sqlite3_value) C.int {
+	args := make([]any, len(argv))
+	for i := range argv {
+		args[i] = sqlValue(argv[i])
+	}
+
+	if err := vtabCall(c.name, func() error { return c.cur.Filter(idxNum, idxStr, args) }); err != nil {
+		return vtabError(vt, err)
+	}
+
+	return SQLITE_OK
+}
+
+func (c *goCursor) next(vt *C.sqlite3_vtab) C.int {
+	if err := vtabCall(c.name, c.cur.Next); err != nil {
+		return vtabError(vt, err)
+	}
+
+	return SQLITE_OK
+}
+
+// eof is true, if EOF() panics.
+func (c *goCursor) eof() C.int {
+	eof := true
+	vtabCall(c.name, func() error {
+		eof = c.cur.EOF()
+		return nil
+	})
+
+	if eof {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *goCursor) column(vt *C.sqlite3_vtab, ctx *C.sqlite3_context, i int) C.int {
+	err := vtabCall(c.name, func() error {
+		v, err := c.cur.Column(i)
+		if err != nil {
+			return err
+		}
+		if err := setResult(ctx, v); err != nil {
+			return fmt.Errorf("%s: column %d: %w", c.name, i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return vtabError(vt, err)
+	}
+
+	return SQLITE_OK
+}
+
+func (c *goCursor) rowid(vt *C.sqlite3_vtab, pRowid *C.sqlite3_int64) C.int {
+	err := vtabCall(c.name, func() error {
+		id, err := c.cur.Rowid()
+		*pRowid = C.sqlite3_int64(id)
+		return err
+	})
+	if err != nil {
+		return vtabError(vt, err)
+	}
+
+	return SQLITE_OK
+}
+
+// close closes the cursor and deletes its handle; the
+// error of Close() is ignored (as by sqlite3).
+func (c *goCursor) close(h cgo.Handle) C.int {
+	vtabCall(c.name, c.cur.Close)
+	h.Delete()
+
+	return SQLITE_OK
+}
@@ -0,0 +1,453 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SliceTable is a Module of a slice of structs; each field is a column,
+// named by its db tag or by the field name (as by Rows.ScanStruct()),
+// and each element is a row. e.g.
+//
+//	type Person struct {
+//		ID   int    `db:"id"`
+//		Name string `db:"name"`
+//	}
+//	...
+//	people, err := gosqlite.NewSliceTable([]Person{{1, "Alice"}, {2, "Bob"}})
+//	err = db.RegisterModule("people", people, gosqlite.ModuleOptions{})
+//	...
+//	rows, err := db.Query("select p.name, o.total from people p join orders o on o.person_id = p.id")
+//
+// The rows can be changed by SQL (INSERT, UPDATE and DELETE) unless
+// ReadOnly is set, and from Go by Set() and Append(); a query sees the
+// rows as they were when it started. The rowid of a row is kept as long
+// as it is in the table; the rows of NewSliceTable() and Set() are
+// numbered from 1.
+type SliceTable[T any] struct {
+	// ReadOnly makes the table read-only for SQL; it is set
+	// before the table is registered.
+	ReadOnly bool
+
+	mu     sync.RWMutex
+	rows   []T
+	ids    []int64 // ascending
+	nextID int64
+	cols   []sliceColumn
+
+	// snap is a copy of the rows for the scans; it is shared by
+	// the cursors until the rows change (nil once they do).
+	snap atomic.Pointer[sliceSnapshot[T]]
+}
+
+// sliceSnapshot is a copy of the rows of a SliceTable.
+type sliceSnapshot[T any] struct {
+	rows []T
+	ids  []int64
+}
+
+// sliceColumn is a column of a SliceTable.
+type sliceColumn struct {
+	name  string
+	decl  string
+	index []int
+}
+
+var valuerType = reflect.TypeFor[driver.Valuer]()
+
+// errRowidExists is the error of an INSERT (or of an UPDATE of
+// the rowid) with the rowid of another row.
+var errRowidExists = &Error{Code: SQLITE_CONSTRAINT, ExtendedCode: SQLITE_CONSTRAINT_ROWID, Message: "UNIQUE constraint failed: rowid", Offset: -1}
+
+// NewSliceTable returns a table of the rows; T must be a struct.
+func NewSliceTable[T any](rows []T) (*SliceTable[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+
+	st := SliceTable[T]{}
+
+	seen := make(map[string]bool)
+	for _, f := range structFields(t, nil) {
+		name := f.tag
+		if name == "" {
+			name = f.name
+		}
+		if seen[strings.ToLower(name)] {
+			// a field of an embedded struct is shadowed
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		ft := t.FieldByIndex(f.index).Type
+		if !funcArgSupported(ft) && !ft.Implements(valuerType) {
+			return nil, fmt.Errorf("%s: field %s: type %s is not supported", t, f.name, ft)
+		}
+
+		st.cols = append(st.cols, sliceColumn{name: name, decl: columnDecl(ft), index: f.index})
+	}
+	if len(st.cols) == 0 {
+		return nil, fmt.Errorf("%s has no exported fields", t)
+	}
+
+	st.Set(rows)
+
+	return &st, nil
+}
+
+// columnDecl is the declared type of a column of a field; i.e. its
+// affinity. See: https://www.sqlite.org/datatype3.html
+func columnDecl(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return "TEXT"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Bool:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.String:
+		return "TEXT"
+	case reflect.Slice:
+		return "BLOB"
+	}
+
+	// any type
+	return ""
+}
+
+// Set replaces the rows of the table.
+func (st *SliceTable[T]) Set(rows []T) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.rows = slices.Clone(rows)
+	st.ids = make([]int64, len(rows))
+	for i := range st.ids {
+		st.ids[i] = int64(i + 1)
+	}
+	st.nextID = int64(len(rows) + 1)
+	st.snap.Store(nil)
+}
+
+// Append adds rows to the table.
+func (st *SliceTable[T]) Append(rows ...T) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, r := range rows {
+		st.rows = append(st.rows, r)
+		st.ids = append(st.ids, st.nextID)
+		st.nextID++
+	}
+	st.snap.Store(nil)
+}
+
+// Rows returns a copy of the rows of the table.
+func (st *SliceTable[T]) Rows() []T {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return slices.Clone(st.rows)
+}
+
+// Len is the number of rows of the table.
+func (st *SliceTable[T]) Len() int {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return len(st.rows)
+}
+
+// Connect implements Module.
+func (st *SliceTable[T]) Connect(args []string) (VTab, string, error) {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE x(")
+	for i, c := range st.cols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quoteIdent(c.name))
+		if c.decl != "" {
+			sb.WriteString(" " + c.decl)
+		}
+	}
+	sb.WriteString(")")
+
+	return &sliceVTab[T]{st: st}, sb.String(), nil
+}
+
+// snapshot returns the copy of the rows for a scan, made once
+// per change of the rows; the caller must hold mu (for read).
+func (st *SliceTable[T]) snapshot() *sliceSnapshot[T] {
+	if sn := st.snap.Load(); sn != nil {
+		return sn
+	}
+
+	sn := &sliceSnapshot[T]{rows: slices.Clone(st.rows), ids: slices.Clone(st.ids)}
+	if !st.snap.CompareAndSwap(nil, sn) {
+		// made by another cursor
+		return st.snap.Load()
+	}
+
+	return sn
+}
+
+// find returns the index of a rowid; the caller must hold mu.
+func (st *SliceTable[T]) find(rowid int64) (int, bool) {
+	return slices.BinarySearch(st.ids, rowid)
+}
+
+// insert inserts a row by its rowid; the caller must hold mu.
+func (st *SliceTable[T]) insert(rowid int64, r T) error {
+	i, found := st.find(rowid)
+	if found {
+		return errRowidExists
+	}
+
+	st.rows = slices.Insert(st.rows, i, r)
+	st.ids = slices.Insert(st.ids, i, rowid)
+	st.nextID = max(st.nextID, rowid+1)
+	st.snap.Store(nil)
+
+	return nil
+}
+
+// remove removes a row by its rowid; the caller must hold mu.
+func (st *SliceTable[T]) remove(rowid int64) bool {
+	i, found := st.find(rowid)
+	if !found {
+		return false
+	}
+
+	st.rows = slices.Delete(st.rows, i, i+1)
+	st.ids = slices.Delete(st.ids, i, i+1)
+	st.snap.Store(nil)
+
+	return true
+}
+
+// sliceVTab is the VTab of a SliceTable.
+type sliceVTab[T any] struct {
+	st *SliceTable[T]
+}
+
+// The plans of sliceVTab.BestIndex().
+const (
+	sliceScan = iota
+	sliceRowid
+)
+
+// BestIndex uses a rowid = ? constraint; the other constraints
+// are checked by sqlite3.
+func (vt *sliceVTab[T]) BestIndex(info *IndexInfo) error {
+	for i, c := range info.Constraints {
+		if c.Usable && c.Column == -1 && c.Op == ConstraintEQ {
+			info.Constraints[i].ArgIndex = 1
+			info.Constraints[i].Omit = true
+			info.IdxNum = sliceRowid
+			info.EstimatedCost = 1
+			info.EstimatedRows = 1
+			info.Unique = true
+			return nil
+		}
+	}
+
+	n := vt.st.Len()
+	info.IdxNum = sliceScan
+	info.EstimatedCost = float64(n)
+	info.EstimatedRows = int64(n)
+
+	return nil
+}
+
+func (vt *sliceVTab[T]) Open() (VTabCursor, error) {
+	return &sliceCursor[T]{st: vt.st}, nil
+}
+
+func (vt *sliceVTab[T]) Disconnect() error {
+	return nil
+}
+
+// row makes a row of the values of the columns.
+func (vt *sliceVTab[T]) row(values []any) (T, error) {
+	var r T
+
+	cols := make([]string, len(vt.st.cols))
+	for i, c := range vt.st.cols {
+		cols[i] = c.name
+	}
+
+	err := setStructFields(reflect.ValueOf(&r).Elem(), cols, func(i int) any {
+		return values[i]
+	})
+
+	return r, err
+}
+
+// Insert implements VTabUpdater.
+func (vt *sliceVTab[T]) Insert(rowid *int64, values []any) (int64, error) {
+	if vt.st.ReadOnly {
+		return 0, errVTabReadOnly
+	}
+
+	r, err := vt.row(values)
+	if err != nil {
+		return 0, err
+	}
+
+	vt.st.mu.Lock()
+	defer vt.st.mu.Unlock()
+
+	id := vt.st.nextID
+	if rowid != nil {
+		id = *rowid
+	}
+
+	return id, vt.st.insert(id, r)
+}
+
+// Update implements VTabUpdater.
+func (vt *sliceVTab[T]) Update(rowid, newRowid int64, values []any) error {
+	if vt.st.ReadOnly {
+		return errVTabReadOnly
+	}
+
+	r, err := vt.row(values)
+	if err != nil {
+		return err
+	}
+
+	vt.st.mu.Lock()
+	defer vt.st.mu.Unlock()
+
+	i, found := vt.st.find(rowid)
+	if !found {
+		return fmt.Errorf("rowid %d not found", rowid)
+	}
+
+	if newRowid == rowid {
+		vt.st.rows[i] = r
+		vt.st.snap.Store(nil)
+		return nil
+	}
+
+	if _, found := vt.st.find(newRowid); found {
+		return errRowidExists
+	}
+	vt.st.remove(rowid)
+
+	return vt.st.insert(newRowid, r)
+}
+
+// Delete implements VTabUpdater.
+func (vt *sliceVTab[T]) Delete(rowid int64) error {
+	if vt.st.ReadOnly {
+		return errVTabReadOnly
+	}
+
+	vt.st.mu.Lock()
+	defer vt.st.mu.Unlock()
+
+	vt.st.remove(rowid)
+
+	return nil
+}
+
+// sliceCursor is a cursor of a SliceTable; a scan iterates over
+// the snapshot of the rows (which is not changed; so it is not
+// copied for each cursor or for each Filter() of a join).
+type sliceCursor[T any] struct {
+	st   *SliceTable[T]
+	rows []T
+	ids  []int64
+	pos  int
+}
+
+func (c *sliceCursor[T]) Filter(idxNum int, idxStr string, args []any) error {
+	c.st.mu.RLock()
+	defer c.st.mu.RUnlock()
+
+	c.pos = 0
+	c.rows, c.ids = nil, nil
+
+	if idxNum != sliceRowid {
+		sn := c.st.snapshot()
+		c.rows, c.ids = sn.rows, sn.ids
+		return nil
+	}
+
+	var rowid int64
+	switch v := args[0].(type) {
+	case int64:
+		rowid = v
+	case float64:
+		if v != float64(int64(v)) {
+			return nil
+		}
+		rowid = int64(v)
+	default:
+		// not an integer; no row
+		return nil
+	}
+
+	if i, found := c.st.find(rowid); found {
+		c.rows = []T{c.st.rows[i]}
+		c.ids = []int64{rowid}
+	}
+
+	return nil
+}
+
+func (c *sliceCursor[T]) Next() error {
+	c.pos++
+	return nil
+}
+
+func (c *sliceCursor[T]) EOF() bool {
+	return c.pos >= len(c.rows)
+}
+
+func (c *sliceCursor[T]) Column(col int) (any, error) {
+	v := reflect.ValueOf(&c.rows[c.pos]).Elem()
+
+	for i, x := range c.st.cols[col].index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				// a nil embedded struct
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v.Interface(), nil
+}
+
+func (c *sliceCursor[T]) Rowid() (int64, error) {
+	return c.ids[c.pos], nil
+}
+
+func (c *sliceCursor[T]) Close() error {
+	c.rows, c.ids = nil, nil
+	return nil
+}
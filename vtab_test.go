@@ -0,0 +1,102 @@
+// Copyright (C) 2024 Kamiar Bahri.
+// Use of this source code is governed by
+// Boost Software License - Version 1.0
+// that can be found in the LICENSE file.
+
+package gosqlite
+
+import (
+	"slices"
+	"testing"
+)
+
+type vtabPerson struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+// openSliceTable registers a SliceTable of people as the
+// "people" module and creates the table p of it.
+func openSliceTable(t *testing.T, readOnly bool) (*DB, *SliceTable[vtabPerson]) {
+	t.Helper()
+
+	db := openTestDB(t, false)
+
+	st, err := NewSliceTable([]vtabPerson{{1, "Alice"}, {2, "Bob"}, {3, "Carol"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.ReadOnly = readOnly
+
+	if err := db.RegisterModule("people", st, ModuleOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db, "create virtual table p using people")
+
+	return db, st
+}
+
+func TestSliceTableQuery(t *testing.T) {
+	db, _ := openSliceTable(t, true)
+	mustExec(t, db, "create table orders(person_id, total)")
+	mustExec(t, db, "insert into orders values(1, 10), (1, 20), (3, 5)")
+
+	type total struct {
+		Name  string `db:"name"`
+		Total int    `db:"total"`
+	}
+	got, err := QueryAll[total](db, `select p.name, sum(o.total) total from p
+		join orders o on o.person_id = p.id group by p.name order by p.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []total{{"Alice", 30}, {"Carol", 5}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// a join of the table with itself
+	if n := count(t, db, "select count(*) from p a join p b on a.id < b.id"); n != 3 {
+		t.Fatalf("got %d pairs, want 3", n)
+	}
+
+	// by rowid
+	name, err := QueryOne[string](db, "select name from p where rowid = 2")
+	if err != nil || name != "Bob" {
+		t.Fatalf("got %q, %v", name, err)
+	}
+
+	// the eponymous table
+	if n := count(t, db, "select count(*) from people"); n != 3 {
+		t.Fatalf("got %d rows, want 3", n)
+	}
+
+	if res := db.Exec("delete from p"); res.Error() == nil {
+		t.Fatal("a read-only table was changed")
+	}
+}
+
+func TestSliceTableUpdate(t *testing.T) {
+	db, st := openSliceTable(t, false)
+
+	mustExec(t, db, "update p set name = name || '!' where id > 1")
+	mustExec(t, db, "delete from p where id = 1")
+
+	// the insert reads the rows as they were when it started
+	mustExec(t, db, "insert into p(rowid, id, name) select rowid + 10, id + 10, name from p")
+
+	want := []vtabPerson{{2, "Bob!"}, {3, "Carol!"}, {12, "Bob!"}, {13, "Carol!"}}
+	if got := st.Rows(); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// changes from Go are seen by the next query
+	st.Append(vtabPerson{99, "Zed"})
+	if n := count(t, db, "select count(*) from p"); n != 5 {
+		t.Fatalf("got %d rows, want 5", n)
+	}
+
+	if res := db.Exec("insert into p(rowid, id, name) values(2, 0, 'x')"); res.Error() == nil {
+		t.Fatal("a duplicate rowid was inserted")
+	}
+}
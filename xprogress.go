@@ -5,8 +5,13 @@
 
 package gosqlite
 
+//#include <stdint.h>
 //#include "sqlite3.h"
 import "C"
+import (
+	"context"
+	"runtime/cgo"
+)
 
 // go_xProgress is the pregress on the number of content pages
 // written to the source database. See BackupOnlineDBFile().
@@ -16,13 +21,41 @@ func go_xProgress(x C.int, y C.int, pDb *C.sqlite3, err *C.char, userData *C.cha
 
 	// Find the caller's database in the group; and call the
 	// progress callback if it is set.
-	for i := 0; i < len(DBGrp.Base().OpenDatabases); i++ {
-		if DBGrp.Base().OpenDatabases[i].DBHwnd == pDb {
+	for _, db := range DBGrp.Base().list() {
+		if db.DBHwnd == pDb {
 			// call the callback func if present.
-			if DBGrp.Base().OpenDatabases[i].BackupProgress != nil {
-				DBGrp.Base().OpenDatabases[i].BackupProgress(int(x), int(y), C.GoString(err), C.GoString(userData))
+			if db.BackupProgress != nil {
+				db.BackupProgress(int(x), int(y), C.GoString(err), C.GoString(userData))
 			}
 			break
 		}
 	}
 }
+
+// go_xStmtProgress is called periodically while a statement runs;
+// a non-zero return aborts the statement. See watchContext().
+//
+//export go_xStmtProgress
+func go_xStmtProgress(h C.uintptr_t) C.int {
+	ctx := cgo.Handle(h).Value().(context.Context)
+	if ctx.Err() != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// go_xBusy is the busy handler of a connection; a non-zero
+// return tries to get the lock again. See SetBusyHandler().
+//
+//export go_xBusy
+func go_xBusy(h C.uintptr_t, count C.int) C.int {
+	// the handler may have been removed
+	// since the connection was set up.
+	fn := cgo.Handle(h).Value().(*busyState).handler()
+	if fn != nil && fn(int(count)) {
+		return 1
+	}
+
+	return 0
+}